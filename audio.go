@@ -0,0 +1,182 @@
+package uiscom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrUnknownAudioFormat audio format not recognized
+var ErrUnknownAudioFormat = errors.New("unknown audio format")
+
+// AudioDuration duration of WAV or MP3 record, MP3 duration estimated by first frame bitrate
+func AudioDuration(r io.ReaderAt, size int64) (time.Duration, error) {
+	head := make([]byte, 12)
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return 0, err
+	}
+	switch {
+	case bytes.Equal(head[0:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WAVE")):
+		return wavDuration(r, size)
+	default:
+		return mp3Duration(r, size)
+	}
+}
+
+// AudioChannels channels count of WAV or MP3 record
+func AudioChannels(r io.ReaderAt, size int64) (int, error) {
+	head := make([]byte, 12)
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return 0, err
+	}
+	if bytes.Equal(head[0:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WAVE")) {
+		fmtChunk, err := wavFormat(r, size)
+		if err != nil {
+			return 0, err
+		}
+		return int(binary.LittleEndian.Uint16(fmtChunk[2:4])), nil
+	}
+	_, header, err := mp3Frame(r)
+	if err != nil {
+		return 0, err
+	}
+	// channel mode 3 is single channel
+	if header[3]>>6 == 3 {
+		return 1, nil
+	}
+	return 2, nil
+}
+
+// wavFormat first 16 bytes of "fmt " chunk
+func wavFormat(r io.ReaderAt, size int64) ([]byte, error) {
+	offset := int64(12)
+	chunk := make([]byte, 8)
+	for offset+8 <= size {
+		if _, err := r.ReadAt(chunk, offset); err != nil {
+			return nil, err
+		}
+		length := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		if string(chunk[0:4]) == "fmt " {
+			fmtChunk := make([]byte, 16)
+			if _, err := r.ReadAt(fmtChunk, offset+8); err != nil {
+				return nil, err
+			}
+			return fmtChunk, nil
+		}
+		offset += 8 + length + length%2
+	}
+	return nil, ErrUnknownAudioFormat
+}
+
+func wavDuration(r io.ReaderAt, size int64) (time.Duration, error) {
+	var byteRate uint32
+	offset := int64(12)
+	chunk := make([]byte, 8)
+	for offset+8 <= size {
+		if _, err := r.ReadAt(chunk, offset); err != nil {
+			return 0, err
+		}
+		id := string(chunk[0:4])
+		length := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		switch id {
+		case "fmt ":
+			fmtChunk := make([]byte, 16)
+			if _, err := r.ReadAt(fmtChunk, offset+8); err != nil {
+				return 0, err
+			}
+			byteRate = binary.LittleEndian.Uint32(fmtChunk[8:12])
+		case "data":
+			if byteRate == 0 {
+				return 0, ErrUnknownAudioFormat
+			}
+			if offset+8+length > size {
+				length = size - offset - 8
+			}
+			return time.Duration(length) * time.Second / time.Duration(byteRate), nil
+		}
+		offset += 8 + length + length%2
+	}
+	return 0, ErrUnknownAudioFormat
+}
+
+var (
+	mp3Bitrates = [2][16]int{
+		// MPEG-1 Layer III
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		// MPEG-2/2.5 Layer III
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	}
+	mp3SampleRates = [4][4]int{
+		{11025, 12000, 8000, 0},  // MPEG-2.5
+		{0, 0, 0, 0},             // reserved
+		{22050, 24000, 16000, 0}, // MPEG-2
+		{44100, 48000, 32000, 0}, // MPEG-1
+	}
+)
+
+func mp3Duration(r io.ReaderAt, size int64) (time.Duration, error) {
+	offset, header, err := mp3Frame(r)
+	if err != nil {
+		return 0, err
+	}
+	table := 1
+	if header.version() == 3 {
+		table = 0
+	}
+	bitrate := mp3Bitrates[table][header.bitrateIndex()] * 1000
+	return time.Duration((size-offset)*8) * time.Second / time.Duration(bitrate), nil
+}
+
+// mp3Header MPEG audio frame header
+type mp3Header []byte
+
+func (h mp3Header) version() byte {
+	return (h[1] >> 3) & 0x03
+}
+
+func (h mp3Header) bitrateIndex() byte {
+	return h[2] >> 4
+}
+
+// mp3Frame offset and header of the first Layer III frame after ID3v2 tag
+func mp3Frame(r io.ReaderAt) (int64, mp3Header, error) {
+	offset := int64(0)
+	id3 := make([]byte, 10)
+	if _, err := r.ReadAt(id3, 0); err != nil {
+		return 0, nil, err
+	}
+	if bytes.Equal(id3[0:3], []byte("ID3")) {
+		offset = 10 + (int64(id3[6]&0x7f)<<21 | int64(id3[7]&0x7f)<<14 | int64(id3[8]&0x7f)<<7 | int64(id3[9]&0x7f))
+	}
+
+	buf := make([]byte, 4096)
+	n, err := r.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	buf = buf[:n]
+	for i := 0; i+4 <= len(buf); i++ {
+		if buf[i] != 0xff || buf[i+1]&0xe0 != 0xe0 {
+			continue
+		}
+		header := mp3Header(buf[i : i+4])
+		version := header.version()
+		layer := (buf[i+1] >> 1) & 0x03
+		bitrateIndex := header.bitrateIndex()
+		sampleRateIndex := (buf[i+2] >> 2) & 0x03
+		if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+			continue
+		}
+		table := 1
+		if version == 3 {
+			table = 0
+		}
+		if mp3Bitrates[table][bitrateIndex] == 0 || mp3SampleRates[version][sampleRateIndex] == 0 {
+			continue
+		}
+		return offset + int64(i), header, nil
+	}
+	return 0, nil, ErrUnknownAudioFormat
+}
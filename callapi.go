@@ -0,0 +1,216 @@
+package uiscom
+
+import (
+	"context"
+	"fmt"
+	"github.com/ybbus/jsonrpc/v3"
+)
+
+const (
+	CallTargetComagic = Target("https://callapi.comagic.ru/v4.0")
+	CallTargetUiscom  = Target("https://callapi.uiscom.ru/v4.0")
+)
+
+// CallClient Call API client for originating and controlling calls
+type CallClient struct {
+	client      jsonrpc.RPCClient
+	AccessToken string
+}
+
+func NewCallClientWithToken(target Target, token string) *CallClient {
+	client := CallClient{
+		client:      newRPCClient(target.URL()),
+		AccessToken: token,
+	}
+	return &client
+}
+
+func (c CallClient) callFor(ctx context.Context, out any, method string, params map[string]any) error {
+	params["access_token"] = c.AccessToken
+	return rpcCallFor(ctx, c.client, out, method, params)
+}
+
+type FirstCall string
+
+func (f FirstCall) String() string {
+	return string(f)
+}
+
+const (
+	FirstCallEmployee = FirstCall("employee")
+	FirstCallContact  = FirstCall("contact")
+)
+
+// CallEmployee employee in call, PhoneNumber optional (employee default number used)
+type CallEmployee struct {
+	ID          int64  `json:"id"`
+	PhoneNumber string `json:"phone_number,omitempty"`
+}
+
+// SimpleCall start.simple_call parameters
+type SimpleCall struct {
+	FirstCall              FirstCall `json:"first_call"`
+	SwitchAtOnce           bool      `json:"switch_at_once,omitempty"`
+	EarlySwitching         bool      `json:"early_switching,omitempty"`
+	MediaFileID            int64     `json:"media_file_id,omitempty"`
+	VirtualPhoneNumber     string    `json:"virtual_phone_number"`
+	ShowVirtualPhoneNumber bool      `json:"show_virtual_phone_number,omitempty"`
+	ExternalID             string    `json:"external_id,omitempty"`
+	DTMFString             string    `json:"dtmf_string,omitempty"`
+	Contact                string    `json:"contact"`
+	Operator               string    `json:"operator"`
+	ContactMessage         string    `json:"contact_message,omitempty"`
+	OperatorMessage        string    `json:"operator_message,omitempty"`
+}
+
+// EmployeeCall start.employee_call parameters
+type EmployeeCall struct {
+	FirstCall              FirstCall    `json:"first_call"`
+	SwitchAtOnce           bool         `json:"switch_at_once,omitempty"`
+	EarlySwitching         bool         `json:"early_switching,omitempty"`
+	MediaFileID            int64        `json:"media_file_id,omitempty"`
+	VirtualPhoneNumber     string       `json:"virtual_phone_number"`
+	ShowVirtualPhoneNumber bool         `json:"show_virtual_phone_number,omitempty"`
+	ExternalID             string       `json:"external_id,omitempty"`
+	DTMFString             string       `json:"dtmf_string,omitempty"`
+	Direction              string       `json:"direction,omitempty"`
+	Contact                string       `json:"contact"`
+	Employee               CallEmployee `json:"employee"`
+	ContactMessage         string       `json:"contact_message,omitempty"`
+	EmployeeMessage        string       `json:"employee_message,omitempty"`
+}
+
+// ScenarioCall start.scenario_call parameters
+type ScenarioCall struct {
+	FirstCall              FirstCall `json:"first_call,omitempty"`
+	ScenarioID             int64     `json:"scenario_id"`
+	VirtualPhoneNumber     string    `json:"virtual_phone_number"`
+	ShowVirtualPhoneNumber bool      `json:"show_virtual_phone_number,omitempty"`
+	ExternalID             string    `json:"external_id,omitempty"`
+	DTMFString             string    `json:"dtmf_string,omitempty"`
+	Contact                string    `json:"contact"`
+	ContactMessage         string    `json:"contact_message,omitempty"`
+}
+
+// TransferCall transfer.talk parameters, one of Employee or PhoneNumber
+type TransferCall struct {
+	CallSessionID int64         `json:"call_session_id"`
+	Employee      *CallEmployee `json:"employee,omitempty"`
+	PhoneNumber   string        `json:"phone_number,omitempty"`
+	Blind         bool          `json:"blind,omitempty"`
+}
+
+// StartCallResponse start.* result
+//
+//	{
+//	 "data": {"call_session_id": "number"}
+//	}
+type StartCallResponse struct {
+	Data struct {
+		CallSessionID int64 `json:"call_session_id"`
+	} `json:"data"`
+}
+
+// ActiveCallsResponse list.calls result
+type ActiveCallsResponse struct {
+	Data []ActiveCall `json:"data"`
+}
+
+// ActiveCall
+//
+//	{
+//	 "call_session_id": "number",
+//	 "start_time": "iso8601",
+//	 "virtual_phone_number": "string",
+//	 "direction": "string",
+//	 "external_id": "string",
+//	 "legs": [ActiveCallLeg]
+//	}
+type ActiveCall struct {
+	CallSessionID      int64           `json:"call_session_id"`
+	StartTime          DateTime        `json:"start_time"`
+	VirtualPhoneNumber string          `json:"virtual_phone_number"`
+	Direction          string          `json:"direction"`
+	ExternalID         *string         `json:"external_id"`
+	Legs               []ActiveCallLeg `json:"legs"`
+}
+
+type ActiveCallLeg struct {
+	LegID         int64   `json:"leg_id"`
+	PhoneNumber   string  `json:"phone_number"`
+	EmployeeID    *int64  `json:"employee_id"`
+	State         string  `json:"state"`
+	IsOperator    bool    `json:"is_operator"`
+	Direction     string  `json:"direction"`
+	CallingNumber *string `json:"calling_phone_number"`
+	CalledNumber  *string `json:"called_phone_number"`
+}
+
+// StartSimpleCall call between contact and operator number, return call_session_id
+func (c CallClient) StartSimpleCall(ctx context.Context, call SimpleCall) (int64, error) {
+	return c.startCall(ctx, "start.simple_call", call)
+}
+
+// StartEmployeeCall call between contact and employee, return call_session_id
+func (c CallClient) StartEmployeeCall(ctx context.Context, call EmployeeCall) (int64, error) {
+	return c.startCall(ctx, "start.employee_call", call)
+}
+
+// StartScenarioCall call contact and process it by scenario, return call_session_id
+func (c CallClient) StartScenarioCall(ctx context.Context, call ScenarioCall) (int64, error) {
+	return c.startCall(ctx, "start.scenario_call", call)
+}
+
+func (c CallClient) ReleaseCall(ctx context.Context, callSessionID int64) error {
+	return c.sessionCall(ctx, "release.call", callSessionID)
+}
+
+func (c CallClient) HoldCall(ctx context.Context, callSessionID int64) error {
+	return c.sessionCall(ctx, "hold.call", callSessionID)
+}
+
+func (c CallClient) UnholdCall(ctx context.Context, callSessionID int64) error {
+	return c.sessionCall(ctx, "unhold.call", callSessionID)
+}
+
+// TransferCall transfer talk to employee or phone number, error without request
+// if both or none of them set
+func (c CallClient) TransferCall(ctx context.Context, transfer TransferCall) error {
+	if (transfer.Employee == nil) == (transfer.PhoneNumber == "") {
+		return fmt.Errorf("transfer.talk: exactly one of employee or phone_number required")
+	}
+	params, err := structToParams(transfer)
+	if err != nil {
+		return err
+	}
+	var resp any
+	return c.callFor(ctx, &resp, "transfer.talk", params)
+}
+
+// ListCalls active calls
+func (c CallClient) ListCalls(ctx context.Context) ([]ActiveCall, error) {
+	var resp ActiveCallsResponse
+	err := c.callFor(ctx, &resp, "list.calls", map[string]any{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (c CallClient) startCall(ctx context.Context, method string, call any) (int64, error) {
+	params, err := structToParams(call)
+	if err != nil {
+		return 0, err
+	}
+	var resp StartCallResponse
+	err = c.callFor(ctx, &resp, method, params)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Data.CallSessionID, nil
+}
+
+func (c CallClient) sessionCall(ctx context.Context, method string, callSessionID int64) error {
+	var resp any
+	return c.callFor(ctx, &resp, method, map[string]any{"call_session_id": callSessionID})
+}
@@ -0,0 +1,42 @@
+package uiscom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransferCallValidation(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":0,"result":{"data":{}}}`))
+	}))
+	defer server.Close()
+	c := NewCallClientWithToken(Target(server.URL), "token")
+
+	tests := []struct {
+		name     string
+		transfer TransferCall
+		wantErr  bool
+	}{
+		{name: "employee", transfer: TransferCall{CallSessionID: 1, Employee: &CallEmployee{ID: 2}}},
+		{name: "phone number", transfer: TransferCall{CallSessionID: 1, PhoneNumber: "79260000000"}},
+		{name: "both", transfer: TransferCall{CallSessionID: 1, Employee: &CallEmployee{ID: 2}, PhoneNumber: "79260000000"}, wantErr: true},
+		{name: "none", transfer: TransferCall{CallSessionID: 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			err := c.TransferCall(context.Background(), tt.transfer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, expected error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && requests != 0 {
+				t.Errorf("%d requests sent for invalid transfer", requests)
+			}
+		})
+	}
+}
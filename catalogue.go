@@ -0,0 +1,314 @@
+package uiscom
+
+import (
+	"context"
+)
+
+// SitesResponse get.sites result
+type SitesResponse struct {
+	Data     []Site   `json:"data"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// Site
+//
+//	{
+//	 "id": "number",
+//	 "domain_name": "string",
+//	 "default_phone_number": "string",
+//	 "default_scenario_id": "number",
+//	 "user_id": "number",
+//	 "connected_integrations": ["string"],
+//	 "creation_time": "iso8601"
+//	}
+type Site struct {
+	ID                    int64    `json:"id"`
+	DomainName            string   `json:"domain_name"`
+	DefaultPhoneNumber    *string  `json:"default_phone_number"`
+	DefaultScenarioID     *int64   `json:"default_scenario_id"`
+	UserID                *int64   `json:"user_id"`
+	ConnectedIntegrations []string `json:"connected_integrations"`
+	CreationTime          DateTime `json:"creation_time"`
+}
+
+// CampaignsResponse get.campaigns result
+type CampaignsResponse struct {
+	Data     []Campaign `json:"data"`
+	Metadata Metadata   `json:"metadata"`
+}
+
+// Campaign
+//
+//	{
+//	 "id": "number",
+//	 "name": "string",
+//	 "description": "string",
+//	 "status": "string",
+//	 "type": "string",
+//	 "creation_time": "iso8601",
+//	 "site_id": "number",
+//	 "site_domain_name": "string",
+//	 "costs": "number",
+//	 "cost_ratio": "number",
+//	 "cost_ratio_operator": "string",
+//	 "engine": "string",
+//	 "campaign_conditions": {},
+//	 "dynamic_call_tracking_settings": {}
+//	}
+type Campaign struct {
+	ID                          int64                        `json:"id"`
+	Name                        string                       `json:"name"`
+	Description                 *string                      `json:"description"`
+	Status                      string                       `json:"status"`
+	Type                        string                       `json:"type"`
+	CreationTime                DateTime                     `json:"creation_time"`
+	SiteID                      *int64                       `json:"site_id"`
+	SiteDomainName              *string                      `json:"site_domain_name"`
+	Costs                       *float64                     `json:"costs"`
+	CostRatio                   *float64                     `json:"cost_ratio"`
+	CostRatioOperator           *string                      `json:"cost_ratio_operator"`
+	Engine                      *string                      `json:"engine"`
+	CampaignConditions          *CampaignConditions          `json:"campaign_conditions"`
+	DynamicCallTrackingSettings *DynamicCallTrackingSettings `json:"dynamic_call_tracking_settings"`
+}
+
+// CampaignConditions conditions of visitor entrance into the campaign,
+// groups are joined by "or", conditions inside group by "and"
+//
+//	{
+//	 "group_conditions": [
+//	   {
+//	     "conditions": [
+//	       {"type": "string", "operator": "string", "value": "string"}
+//	     ]
+//	   }
+//	 ]
+//	}
+type CampaignConditions struct {
+	GroupConditions []CampaignConditionGroup `json:"group_conditions"`
+}
+
+type CampaignConditionGroup struct {
+	Conditions []CampaignCondition `json:"conditions"`
+}
+
+// CampaignCondition type eg: "referrer", "entrance_page", "utm_source", operator eg: "equal", "contains", "regexp"
+type CampaignCondition struct {
+	Type     string `json:"type"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// DynamicCallTrackingSettings number pool of dynamic call tracking campaign
+//
+//	{
+//	 "virtual_phone_numbers": ["string"],
+//	 "reservation_time": "number",
+//	 "redirection_phone_number": "string",
+//	 "number_pool_type": "string"
+//	}
+type DynamicCallTrackingSettings struct {
+	VirtualPhoneNumbers    []string `json:"virtual_phone_numbers"`
+	ReservationTime        int      `json:"reservation_time,omitempty"`
+	RedirectionPhoneNumber string   `json:"redirection_phone_number,omitempty"`
+	NumberPoolType         string   `json:"number_pool_type,omitempty"`
+}
+
+// VirtualNumbersResponse get.virtual_numbers result
+type VirtualNumbersResponse struct {
+	Data     []VirtualNumber `json:"data"`
+	Metadata Metadata        `json:"metadata"`
+}
+
+// VirtualNumber
+//
+//	{
+//	 "id": "number",
+//	 "virtual_phone_number": "string",
+//	 "activation_date": "iso8601",
+//	 "status": "string",
+//	 "category": "string",
+//	 "type": "string",
+//	 "site_id": "number",
+//	 "site_domain_name": "string",
+//	 "campaign_id": "number",
+//	 "campaign_name": "string",
+//	 "scenarios": [{"id": "number", "name": "string"}]
+//	}
+type VirtualNumber struct {
+	ID                 int64    `json:"id"`
+	VirtualPhoneNumber string   `json:"virtual_phone_number"`
+	ActivationDate     DateTime `json:"activation_date"`
+	Status             string   `json:"status"`
+	Category           string   `json:"category"`
+	Type               string   `json:"type"`
+	SiteID             *int64   `json:"site_id"`
+	SiteDomainName     *string  `json:"site_domain_name"`
+	CampaignID         *int64   `json:"campaign_id"`
+	CampaignName       *string  `json:"campaign_name"`
+	Scenarios          []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"scenarios"`
+}
+
+// ScenariosResponse get.scenarios result
+type ScenariosResponse struct {
+	Data     []Scenario `json:"data"`
+	Metadata Metadata   `json:"metadata"`
+}
+
+// Scenario
+//
+//	{
+//	 "id": "number",
+//	 "name": "string",
+//	 "virtual_phone_numbers": ["string"],
+//	 "site_ids": ["number"],
+//	 "campaign_ids": ["number"]
+//	}
+type Scenario struct {
+	ID                  int64    `json:"id"`
+	Name                string   `json:"name"`
+	VirtualPhoneNumbers []string `json:"virtual_phone_numbers"`
+	SiteIDs             []int64  `json:"site_ids"`
+	CampaignIDs         []int64  `json:"campaign_ids"`
+}
+
+// EmployeesResponse get.employees result
+type EmployeesResponse struct {
+	Data     []Employee `json:"data"`
+	Metadata Metadata   `json:"metadata"`
+}
+
+// Employee
+//
+//	{
+//	 "id": "number",
+//	 "first_name": "string",
+//	 "last_name": "string",
+//	 "patronymic": "string",
+//	 "full_name": "string",
+//	 "status_id": "number",
+//	 "email": "string",
+//	 "extension": {"extension_phone_number": "string"},
+//	 "phone_numbers": [{"phone_number": "string", "channels_amount": "number", "status": "string"}],
+//	 "groups": [{"group_id": "number", "group_name": "string"}]
+//	}
+type Employee struct {
+	ID         int64   `json:"id"`
+	FirstName  string  `json:"first_name"`
+	LastName   *string `json:"last_name"`
+	Patronymic *string `json:"patronymic"`
+	FullName   string  `json:"full_name"`
+	StatusID   *int64  `json:"status_id"`
+	Email      *string `json:"email"`
+	Extension  *struct {
+		ExtensionPhoneNumber *string `json:"extension_phone_number"`
+	} `json:"extension"`
+	PhoneNumbers []struct {
+		PhoneNumber    string `json:"phone_number"`
+		ChannelsAmount *int   `json:"channels_amount"`
+		Status         string `json:"status"`
+	} `json:"phone_numbers"`
+	Groups []struct {
+		GroupID   int64  `json:"group_id"`
+		GroupName string `json:"group_name"`
+	} `json:"groups"`
+}
+
+func (c Client) GetEmployees(ctx context.Context, userID int, limit, offset int, filter *Filter, fields ...Field) (*EmployeesResponse, error) {
+	var resp EmployeesResponse
+	err := c.callFor(ctx, &resp, "get.employees", c.listParams(userID, limit, offset, filter, fields))
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c Client) GetSites(ctx context.Context, userID int, limit, offset int, filter *Filter, fields ...Field) (*SitesResponse, error) {
+	var resp SitesResponse
+	err := c.callFor(ctx, &resp, "get.sites", c.listParams(userID, limit, offset, filter, fields))
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c Client) GetCampaigns(ctx context.Context, userID int, limit, offset int, filter *Filter, fields ...Field) (*CampaignsResponse, error) {
+	var resp CampaignsResponse
+	err := c.callFor(ctx, &resp, "get.campaigns", c.listParams(userID, limit, offset, filter, fields))
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c Client) GetVirtualNumbers(ctx context.Context, userID int, limit, offset int, filter *Filter, fields ...Field) (*VirtualNumbersResponse, error) {
+	var resp VirtualNumbersResponse
+	err := c.callFor(ctx, &resp, "get.virtual_numbers", c.listParams(userID, limit, offset, filter, fields))
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c Client) GetScenarios(ctx context.Context, userID int, limit, offset int, filter *Filter, fields ...Field) (*ScenariosResponse, error) {
+	var resp ScenariosResponse
+	err := c.callFor(ctx, &resp, "get.scenarios", c.listParams(userID, limit, offset, filter, fields))
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SiteParams create.sites/update.sites parameters, zero values not sent,
+// optional values are pointers: nil not sent, pointer to zero value sent as 0 or "",
+// null never sent, so field can't be cleared to null
+type SiteParams struct {
+	ID                 int64   `json:"id,omitempty"`
+	DomainName         string  `json:"domain_name,omitempty"`
+	DefaultPhoneNumber *string `json:"default_phone_number,omitempty"`
+	DefaultScenarioID  *int64  `json:"default_scenario_id,omitempty"`
+}
+
+// CampaignParams create.campaigns/update.campaigns parameters, zero values not sent,
+// optional values are pointers: nil not sent, pointer to zero value sent as 0 or "",
+// null never sent, so field can't be cleared to null
+type CampaignParams struct {
+	ID                          int64                        `json:"id,omitempty"`
+	Name                        string                       `json:"name,omitempty"`
+	Description                 *string                      `json:"description,omitempty"`
+	SiteID                      int64                        `json:"site_id,omitempty"`
+	Status                      string                       `json:"status,omitempty"`
+	Costs                       *float64                     `json:"costs,omitempty"`
+	CostRatio                   *float64                     `json:"cost_ratio,omitempty"`
+	CostRatioOperator           *string                      `json:"cost_ratio_operator,omitempty"`
+	Engine                      *string                      `json:"engine,omitempty"`
+	CampaignConditions          *CampaignConditions          `json:"campaign_conditions,omitempty"`
+	DynamicCallTrackingSettings *DynamicCallTrackingSettings `json:"dynamic_call_tracking_settings,omitempty"`
+}
+
+func (c Client) CreateSite(ctx context.Context, userID int, site SiteParams) (int64, error) {
+	return c.callForID(ctx, "create.sites", userID, site)
+}
+
+func (c Client) UpdateSite(ctx context.Context, userID int, site SiteParams) (int64, error) {
+	return c.callForID(ctx, "update.sites", userID, site)
+}
+
+func (c Client) DeleteSite(ctx context.Context, userID int, id int64) (int64, error) {
+	return c.callForID(ctx, "delete.sites", userID, map[string]any{"id": id})
+}
+
+func (c Client) CreateCampaign(ctx context.Context, userID int, campaign CampaignParams) (int64, error) {
+	return c.callForID(ctx, "create.campaigns", userID, campaign)
+}
+
+func (c Client) UpdateCampaign(ctx context.Context, userID int, campaign CampaignParams) (int64, error) {
+	return c.callForID(ctx, "update.campaigns", userID, campaign)
+}
+
+func (c Client) DeleteCampaign(ctx context.Context, userID int, id int64) (int64, error) {
+	return c.callForID(ctx, "delete.campaigns", userID, map[string]any{"id": id})
+}
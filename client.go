@@ -1,10 +1,12 @@
 package uiscom
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/ybbus/jsonrpc/v3"
+	"net/http"
 	"time"
 )
 
@@ -26,23 +28,39 @@ const (
 type Client struct {
 	client      jsonrpc.RPCClient
 	AccessToken string
+	// MediaURL media records host, default by target
+	MediaURL string
+	// HTTPClient for media requests, nil for http.DefaultClient
+	HTTPClient *http.Client
 }
 
 func NewWithToken(target Target, token string) *Client {
-	c := jsonrpc.NewClientWithOpts(
-		target.URL(),
-		&jsonrpc.RPCClientOpts{
-			DefaultRequestID: int(time.Now().UTC().Unix()),
-		})
 	client := Client{
-		client:      c,
+		client:      newRPCClient(target.URL()),
 		AccessToken: token,
+		MediaURL:    target.MediaURL(),
 	}
 	return &client
 }
 
 func (c Client) call(ctx context.Context, method string, params ...any) (any, error) {
-	resp, err := c.client.Call(ctx, method, params...)
+	return rpcCall(ctx, c.client, method, params...)
+}
+
+func (c Client) callFor(ctx context.Context, out any, method string, params ...any) error {
+	return rpcCallFor(ctx, c.client, out, method, params...)
+}
+
+func newRPCClient(url string) jsonrpc.RPCClient {
+	return jsonrpc.NewClientWithOpts(
+		url,
+		&jsonrpc.RPCClientOpts{
+			DefaultRequestID: int(time.Now().UTC().Unix()),
+		})
+}
+
+func rpcCall(ctx context.Context, client jsonrpc.RPCClient, method string, params ...any) (any, error) {
+	resp, err := client.Call(ctx, method, params...)
 	switch e := err.(type) {
 	case nil:
 	case *jsonrpc.HTTPError:
@@ -57,6 +75,79 @@ func (c Client) call(ctx context.Context, method string, params ...any) (any, er
 	return resp.Result, nil
 }
 
+func rpcCallFor(ctx context.Context, client jsonrpc.RPCClient, out any, method string, params ...any) error {
+	result, err := rpcCall(ctx, client, method, params...)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// objectParams marshal v object fields into method params
+func (c Client) objectParams(userID int, v any) (map[string]any, error) {
+	params, err := structToParams(v)
+	if err != nil {
+		return nil, err
+	}
+	params["access_token"] = c.AccessToken
+	if userID >= 0 {
+		params["user_id"] = userID
+	}
+	return params, nil
+}
+
+func structToParams(v any) (map[string]any, error) {
+	params := map[string]any{}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	err = d.Decode(&params)
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// callForID call create.*, update.* or delete.* method with v object params and return affected id
+func (c Client) callForID(ctx context.Context, method string, userID int, v any) (int64, error) {
+	params, err := c.objectParams(userID, v)
+	if err != nil {
+		return 0, err
+	}
+	var resp IDResponse
+	err = c.callFor(ctx, &resp, method, params)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Data.ID, nil
+}
+
+// listParams params of get.* methods without date range, limit <= 0 omit paging
+func (c Client) listParams(userID, limit, offset int, filter *Filter, fields []Field) map[string]any {
+	params := map[string]any{"access_token": c.AccessToken}
+	if userID >= 0 {
+		params["user_id"] = userID
+	}
+	if limit > 0 {
+		params["limit"] = limit
+		params["offset"] = offset
+	}
+	if filter != nil {
+		params["filter"] = json.RawMessage(filter.JsonPart())
+	}
+	if fields != nil {
+		params["fields"] = fields
+	}
+	return params
+}
+
 func (c Client) GetAccount(ctx context.Context) (any, error) {
 	return c.call(ctx, "get.account", map[string]string{"access_token": c.AccessToken})
 }
@@ -115,3 +206,27 @@ func (c Client) GetEmployeeStat(ctx context.Context, userID int, dateFrom, dateT
 	}
 	return c.call(ctx, "get.employee_stat", params)
 }
+
+func (c Client) GetFinancialCallLegsReport(ctx context.Context, userID int, dateFrom, dateTill time.Time, limit, offset int, filter *Filter, fields ...Field) (*FinancialCallLegsReport, error) {
+	params := map[string]any{"access_token": c.AccessToken}
+	if userID >= 0 {
+		params["user_id"] = userID
+	}
+	params["date_from"] = TimeToString(dateFrom)
+	params["date_till"] = TimeToString(dateTill)
+	params["limit"] = limit
+	params["offset"] = offset
+	if filter != nil {
+		params["filter"] = json.RawMessage(filter.JsonPart())
+	}
+	if fields == nil {
+		fields = GetFinancialCallLegsReportResponseParametersFields
+	}
+	params["fields"] = fields
+	var report FinancialCallLegsReport
+	err := c.callFor(ctx, &report, "get.financial_call_legs_report", params)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
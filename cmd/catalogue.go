@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/Supme/uiscom"
+	"log"
+	"time"
+)
+
+// employeeStatSchema employee_stat table, full report row stored in data
+var employeeStatSchema = uiscom.Schema{
+	Table: "employee_stat",
+	Columns: []uiscom.Column{
+		{Name: "employee_id", Type: uiscom.ColumnInt64, Key: true},
+		{Name: "date_from", Type: uiscom.ColumnTime, Key: true},
+		{Name: "date_till", Type: uiscom.ColumnTime, NotNull: true},
+		{Name: "employee_full_name", Type: uiscom.ColumnString, Size: 250},
+		{Name: "data", Type: uiscom.ColumnJSON},
+		{Name: "account", Type: uiscom.ColumnString, Size: 100},
+	},
+}
+
+// catalogueLimit page size of catalogue requests
+const catalogueLimit = 1000
+
+// syncEmployeeStat sync employee stat by hours, last hour may be partial and updated on next run
+func syncEmployeeStat(out sink, a account, from, till time.Time) error {
+	for start := from.Truncate(time.Hour); start.Before(till); start = start.Add(time.Hour) {
+		end := start.Add(time.Hour)
+		if end.After(till) {
+			end = till
+		}
+
+		data, err := fetchAll(10000, func(limit, offset int) (any, error) {
+			return a.client.GetEmployeeStat(context.Background(), -1, start, end, limit, offset, nil, false)
+		})
+		if err != nil {
+			return err
+		}
+
+		rows := make([][]any, 0, len(data))
+		var dead int
+		for _, v := range data {
+			values, err := employeeStatValues(v, start, end)
+			if err != nil {
+				dead++
+				if err := rejectRow(a, entityEmployeeStat, v, err); err != nil {
+					return err
+				}
+				continue
+			}
+			rows = append(rows, append(values, a.name))
+		}
+		stats.add(a.entity(entityEmployeeStat), len(rows), 0, dead)
+
+		err = out.write(employeeStatSchema, rows)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// employeeStatValues employee_stat row values of report row for period
+func employeeStatValues(v any, from, till time.Time) ([]any, error) {
+	row, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("wrong row type %T", v)
+	}
+	id, err := employeeStatSchema.Columns[0].Decode(row["employee_id"])
+	if err != nil {
+		return nil, err
+	}
+	name, err := employeeStatSchema.Columns[3].Decode(row["employee_full_name"])
+	if err != nil && decode.strictness != strictLenient {
+		return nil, err
+	}
+	return []any{id, from, till, name, row}, nil
+}
+
+// syncCatalogues full refresh of employees, scenarios, virtual_numbers, sites and campaigns tables
+func syncCatalogues(ctx context.Context, out sink, a account) error {
+	catalogues := []struct {
+		table string
+		sync  func() error
+	}{
+		{"employees", func() error { return syncEmployees(ctx, out, a) }},
+		{"scenarios", func() error { return syncScenarios(ctx, out, a) }},
+		{"virtual_numbers", func() error { return syncVirtualNumbers(ctx, out, a) }},
+		{"sites", func() error { return syncSites(ctx, out, a) }},
+		{"campaigns", func() error { return syncCampaigns(ctx, out, a) }},
+	}
+	for _, c := range catalogues {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if verbose {
+			log.Printf("%s syncing", c.table)
+		}
+		if err := c.sync(); err != nil {
+			return fmt.Errorf("%s: %w", c.table, err)
+		}
+	}
+	return nil
+}
+
+var employeesSchema = uiscom.Schema{
+	Table: "employees",
+	Columns: []uiscom.Column{
+		{Name: "id", Type: uiscom.ColumnInt64, Key: true},
+		{Name: "full_name", Type: uiscom.ColumnString, Size: 250},
+		{Name: "first_name", Type: uiscom.ColumnString, Size: 100},
+		{Name: "last_name", Type: uiscom.ColumnString, Size: 100},
+		{Name: "patronymic", Type: uiscom.ColumnString, Size: 100},
+		{Name: "status_id", Type: uiscom.ColumnInt64},
+		{Name: "email", Type: uiscom.ColumnString, Size: 250},
+		{Name: "extension_phone_number", Type: uiscom.ColumnString, Size: 20},
+		{Name: "phone_numbers", Type: uiscom.ColumnJSON},
+		{Name: "groups", Type: uiscom.ColumnJSON},
+		{Name: "deleted_at", Type: uiscom.ColumnTime},
+		{Name: "account", Type: uiscom.ColumnString, Size: 100},
+	},
+}
+
+func syncEmployees(ctx context.Context, out sink, a account) error {
+	data, err := fetchCatalogue(func(limit, offset int) ([]uiscom.Employee, error) {
+		resp, err := a.client.GetEmployees(ctx, -1, limit, offset, nil)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Data, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]any, len(data))
+	for i, v := range data {
+		var extension *string
+		if v.Extension != nil {
+			extension = v.Extension.ExtensionPhoneNumber
+		}
+		rows[i] = []any{v.ID, v.FullName, v.FirstName, v.LastName, v.Patronymic, v.StatusID, v.Email,
+			extension, v.PhoneNumbers, v.Groups, nil}
+	}
+	return writeCatalogue(out, employeesSchema, a.name, rows)
+}
+
+var scenariosSchema = uiscom.Schema{
+	Table: "scenarios",
+	Columns: []uiscom.Column{
+		{Name: "id", Type: uiscom.ColumnInt64, Key: true},
+		{Name: "name", Type: uiscom.ColumnString, Size: 250},
+		{Name: "virtual_phone_numbers", Type: uiscom.ColumnJSON},
+		{Name: "site_ids", Type: uiscom.ColumnJSON},
+		{Name: "campaign_ids", Type: uiscom.ColumnJSON},
+		{Name: "deleted_at", Type: uiscom.ColumnTime},
+		{Name: "account", Type: uiscom.ColumnString, Size: 100},
+	},
+}
+
+func syncScenarios(ctx context.Context, out sink, a account) error {
+	data, err := fetchCatalogue(func(limit, offset int) ([]uiscom.Scenario, error) {
+		resp, err := a.client.GetScenarios(ctx, -1, limit, offset, nil)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Data, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]any, len(data))
+	for i, v := range data {
+		rows[i] = []any{v.ID, v.Name, v.VirtualPhoneNumbers, v.SiteIDs, v.CampaignIDs, nil}
+	}
+	return writeCatalogue(out, scenariosSchema, a.name, rows)
+}
+
+var virtualNumbersSchema = uiscom.Schema{
+	Table: "virtual_numbers",
+	Columns: []uiscom.Column{
+		{Name: "id", Type: uiscom.ColumnInt64, Key: true},
+		{Name: "virtual_phone_number", Type: uiscom.ColumnString, Size: 20},
+		{Name: "activation_date", Type: uiscom.ColumnTime},
+		{Name: "status", Type: uiscom.ColumnString, Size: 50},
+		{Name: "category", Type: uiscom.ColumnString, Size: 50},
+		{Name: "type", Type: uiscom.ColumnString, Size: 50},
+		{Name: "site_id", Type: uiscom.ColumnInt64},
+		{Name: "site_domain_name", Type: uiscom.ColumnString, Size: 250},
+		{Name: "campaign_id", Type: uiscom.ColumnInt64},
+		{Name: "campaign_name", Type: uiscom.ColumnString, Size: 250},
+		{Name: "scenarios", Type: uiscom.ColumnJSON},
+		{Name: "deleted_at", Type: uiscom.ColumnTime},
+		{Name: "account", Type: uiscom.ColumnString, Size: 100},
+	},
+}
+
+func syncVirtualNumbers(ctx context.Context, out sink, a account) error {
+	data, err := fetchCatalogue(func(limit, offset int) ([]uiscom.VirtualNumber, error) {
+		resp, err := a.client.GetVirtualNumbers(ctx, -1, limit, offset, nil)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Data, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]any, len(data))
+	for i, v := range data {
+		rows[i] = []any{v.ID, v.VirtualPhoneNumber, nullTime(v.ActivationDate), v.Status, v.Category, v.Type,
+			v.SiteID, v.SiteDomainName, v.CampaignID, v.CampaignName, v.Scenarios, nil}
+	}
+	return writeCatalogue(out, virtualNumbersSchema, a.name, rows)
+}
+
+var sitesSchema = uiscom.Schema{
+	Table: "sites",
+	Columns: []uiscom.Column{
+		{Name: "id", Type: uiscom.ColumnInt64, Key: true},
+		{Name: "domain_name", Type: uiscom.ColumnString, Size: 250},
+		{Name: "default_phone_number", Type: uiscom.ColumnString, Size: 20},
+		{Name: "default_scenario_id", Type: uiscom.ColumnInt64},
+		{Name: "user_id", Type: uiscom.ColumnInt64},
+		{Name: "connected_integrations", Type: uiscom.ColumnJSON},
+		{Name: "creation_time", Type: uiscom.ColumnTime},
+		{Name: "deleted_at", Type: uiscom.ColumnTime},
+		{Name: "account", Type: uiscom.ColumnString, Size: 100},
+	},
+}
+
+func syncSites(ctx context.Context, out sink, a account) error {
+	data, err := fetchCatalogue(func(limit, offset int) ([]uiscom.Site, error) {
+		resp, err := a.client.GetSites(ctx, -1, limit, offset, nil)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Data, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]any, len(data))
+	for i, v := range data {
+		rows[i] = []any{v.ID, v.DomainName, v.DefaultPhoneNumber, v.DefaultScenarioID, v.UserID,
+			v.ConnectedIntegrations, nullTime(v.CreationTime), nil}
+	}
+	return writeCatalogue(out, sitesSchema, a.name, rows)
+}
+
+var campaignsSchema = uiscom.Schema{
+	Table: "campaigns",
+	Columns: []uiscom.Column{
+		{Name: "id", Type: uiscom.ColumnInt64, Key: true},
+		{Name: "name", Type: uiscom.ColumnString, Size: 250},
+		{Name: "description", Type: uiscom.ColumnString},
+		{Name: "status", Type: uiscom.ColumnString, Size: 50},
+		{Name: "type", Type: uiscom.ColumnString, Size: 50},
+		{Name: "creation_time", Type: uiscom.ColumnTime},
+		{Name: "site_id", Type: uiscom.ColumnInt64},
+		{Name: "site_domain_name", Type: uiscom.ColumnString, Size: 250},
+		{Name: "costs", Type: uiscom.ColumnFloat64},
+		{Name: "cost_ratio", Type: uiscom.ColumnFloat64},
+		{Name: "cost_ratio_operator", Type: uiscom.ColumnString, Size: 20},
+		{Name: "engine", Type: uiscom.ColumnString, Size: 100},
+		{Name: "campaign_conditions", Type: uiscom.ColumnJSON},
+		{Name: "dynamic_call_tracking_settings", Type: uiscom.ColumnJSON},
+		{Name: "deleted_at", Type: uiscom.ColumnTime},
+		{Name: "account", Type: uiscom.ColumnString, Size: 100},
+	},
+}
+
+func syncCampaigns(ctx context.Context, out sink, a account) error {
+	data, err := fetchCatalogue(func(limit, offset int) ([]uiscom.Campaign, error) {
+		resp, err := a.client.GetCampaigns(ctx, -1, limit, offset, nil)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Data, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]any, len(data))
+	for i, v := range data {
+		rows[i] = []any{v.ID, v.Name, v.Description, v.Status, v.Type, nullTime(v.CreationTime),
+			v.SiteID, v.SiteDomainName, v.Costs, v.CostRatio, v.CostRatioOperator, v.Engine,
+			v.CampaignConditions, v.DynamicCallTrackingSettings, nil}
+	}
+	return writeCatalogue(out, campaignsSchema, a.name, rows)
+}
+
+// fetchCatalogue request catalogue pages until page shorter than limit
+func fetchCatalogue[T any](fetch func(limit, offset int) ([]T, error)) ([]T, error) {
+	var rows []T
+	for offset := 0; ; offset += catalogueLimit {
+		data, err := fetch(catalogueLimit, offset)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, data...)
+		if len(data) < catalogueLimit {
+			return rows, nil
+		}
+	}
+}
+
+// writeCatalogue write all account catalogue rows with deleted_at reset,
+// in postgres account rows not returned by API soft deleted,
+// empty catalogue not soft delete rows, it may be transient API answer or lost permissions
+func writeCatalogue(out sink, s uiscom.Schema, account string, rows [][]any) error {
+	for i := range rows {
+		rows[i] = append(rows[i], account)
+	}
+	err := out.write(s, rows)
+	if err != nil {
+		return err
+	}
+	p, ok := out.(postgresSink)
+	if !ok {
+		return nil
+	}
+	if len(rows) == 0 {
+		log.Printf("%s empty for account %q, rows not soft deleted", s.Table, account)
+		return nil
+	}
+
+	ids := make([]int64, len(rows))
+	for i := range rows {
+		ids[i] = rows[i][0].(int64)
+	}
+	tag, err := p.dbpool.Exec(context.Background(),
+		`UPDATE `+s.Table+` SET deleted_at = now(), updated_at = now()
+		WHERE deleted_at IS NULL AND account = $1 AND NOT (id = ANY($2))`, account, ids)
+	if err != nil {
+		return fmt.Errorf("soft delete %s: %w", s.Table, err)
+	}
+	if verbose && tag.RowsAffected() != 0 {
+		log.Printf("%s %d rows deleted", s.Table, tag.RowsAffected())
+	}
+	return nil
+}
+
+// nullTime NULL for zero time
+func nullTime(t uiscom.DateTime) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Time
+}
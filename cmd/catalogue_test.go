@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestWriteCatalogueEmptyNotSoftDeleted(t *testing.T) {
+	// without rows nothing written and soft delete skipped, so nil pool never used
+	out := postgresSink{}
+	for _, s := range []struct {
+		name string
+		rows [][]any
+	}{
+		{name: "nil", rows: nil},
+		{name: "empty", rows: [][]any{}},
+	} {
+		t.Run(s.name, func(t *testing.T) {
+			if err := writeCatalogue(out, employeesSchema, "account", s.rows); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configEnvPrefix environment variables prefix, eg: UISCOM_SYNC_DB_PASSWORD
+const configEnvPrefix = "UISCOM_SYNC_"
+
+// configKeys config file keys and environment variables of flags,
+// every key may be read from file by <key>_file for secrets
+var configKeys = []struct {
+	key  string
+	flag string
+}{
+	{"token", "t"},
+	{"db_dsn", "dsn"},
+	{"db_host", "s"},
+	{"db_port", "p"},
+	{"db_name", "n"},
+	{"db_user", "u"},
+	{"db_password", "w"},
+	{"interval", "i"},
+	{"from", "f"},
+	{"overlap", "o"},
+	{"chunk", "cw"},
+	{"refresh_lookback", "rl"},
+	{"media_folder", "m"},
+	{"media_template", "mt"},
+	{"media_spool", "ms"},
+	{"media_manifest", "mf"},
+	{"download_workers", "dw"},
+	{"media_sidecar", "msc"},
+	{"transcode_format", "tf"},
+	{"transcode_sample_rate", "tr"},
+	{"transcode_mono", "tm"},
+	{"transcode_split", "ts"},
+	{"transcode_operator_channel", "to"},
+	{"transcode_encoder", "te"},
+	{"s3_endpoint", "s3e"},
+	{"s3_region", "s3r"},
+	{"s3_bucket", "s3b"},
+	{"s3_prefix", "s3p"},
+	{"s3_access_key", "s3a"},
+	{"s3_secret_key", "s3k"},
+	{"retention_days", "rd"},
+	{"retention_tag_days", "rt"},
+	{"retention_cold", "rc"},
+	{"retention_dry_run", "rdry"},
+	{"daemon", "d"},
+	{"daemon_calls_interval", "dci"},
+	{"daemon_call_legs_interval", "dli"},
+	{"daemon_employee_stat_interval", "dei"},
+	{"daemon_catalogues_interval", "dgi"},
+	{"daemon_retention_interval", "dri"},
+	{"health_addr", "ha"},
+	{"upsert_mode", "um"},
+	{"upsert_history", "uh"},
+	{"auto_migrate", "am"},
+	{"grant_reader", "gr"},
+	{"grant_writer", "gw"},
+	{"sink", "sk"},
+	{"entity_sinks", "ske"},
+	{"sink_dir", "od"},
+	{"strictness", "sm"},
+	{"dead_letters_file", "dlf"},
+	{"verbose", "V"},
+}
+
+// accountConfig one of synced accounts, token or token_file required
+type accountConfig struct {
+	Name      string `yaml:"name" toml:"name"`
+	Token     string `yaml:"token" toml:"token"`
+	TokenFile string `yaml:"token_file" toml:"token_file"`
+}
+
+// config values from YAML or TOML file by file extension
+type config struct {
+	values   map[string]any
+	accounts []accountConfig
+}
+
+func loadConfig(filename string) (config, error) {
+	c := config{values: map[string]any{}}
+	if filename == "" {
+		return c, nil
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return c, err
+	}
+
+	var accounts struct {
+		Accounts []accountConfig `yaml:"accounts" toml:"accounts"`
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &c.values)
+		if err == nil {
+			err = yaml.Unmarshal(b, &accounts)
+		}
+	case ".toml":
+		err = toml.Unmarshal(b, &c.values)
+		if err == nil {
+			err = toml.Unmarshal(b, &accounts)
+		}
+	default:
+		return c, fmt.Errorf("unsupported config file %s, expected .yaml, .yml or .toml", filename)
+	}
+	if err != nil {
+		return c, fmt.Errorf("config %s: %w", filename, err)
+	}
+	delete(c.values, "accounts")
+	c.accounts = accounts.Accounts
+
+	known := map[string]bool{}
+	for _, k := range configKeys {
+		known[k.key] = true
+		known[k.key+"_file"] = true
+	}
+	for k := range c.values {
+		if !known[k] {
+			return c, fmt.Errorf("config %s: unknown key %q", filename, k)
+		}
+	}
+	return c, nil
+}
+
+// applyConfig set flags of parsed fs not given in command line,
+// environment variable overrides config file value, <key>_file read value from file
+func applyConfig(fs *flag.FlagSet, c config) error {
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	for _, k := range configKeys {
+		if explicit[k.flag] {
+			continue
+		}
+		value, ok, err := configValue(c, k.key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := fs.Set(k.flag, value); err != nil {
+			return fmt.Errorf("%s: %w", k.key, err)
+		}
+	}
+	return nil
+}
+
+func configValue(c config, key string) (string, bool, error) {
+	env := configEnvPrefix + strings.ToUpper(key)
+	if v, ok := os.LookupEnv(env); ok {
+		return v, true, nil
+	}
+	if v, ok := os.LookupEnv(env + "_FILE"); ok {
+		s, err := readSecret(v)
+		return s, true, err
+	}
+	if v, ok := c.values[key]; ok {
+		return fmt.Sprint(v), true, nil
+	}
+	if v, ok := c.values[key+"_file"]; ok {
+		s, err := readSecret(fmt.Sprint(v))
+		return s, true, err
+	}
+	return "", false, nil
+}
+
+// readSecret file content without trailing new line
+func readSecret(filename string) (string, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("read secret: %w", err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// token account token from config or file
+func (a accountConfig) token() (string, error) {
+	if a.Token != "" || a.TokenFile == "" {
+		return a.Token, nil
+	}
+	return readSecret(a.TokenFile)
+}
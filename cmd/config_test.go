@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestApplyConfig(t *testing.T) {
+	secret := writeTestFile(t, "password", "from-secret-file\n")
+	configFile := writeTestFile(t, "sync.yaml", "token: from-file\ndb_password_file: "+secret+"\ninterval: 30\ndb_user: file-user\n")
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want map[string]string
+	}{
+		{
+			name: "file",
+			want: map[string]string{"t": "from-file", "w": "from-secret-file", "i": "30", "u": "file-user"},
+		},
+		{
+			name: "env over file",
+			env:  map[string]string{"UISCOM_SYNC_TOKEN": "from-env", "UISCOM_SYNC_DB_PASSWORD_FILE": writeTestFile(t, "env-password", "from-env-file")},
+			want: map[string]string{"t": "from-env", "w": "from-env-file", "i": "30", "u": "file-user"},
+		},
+		{
+			name: "flag over env and file",
+			args: []string{"-t", "from-flag", "-w", "from-flag-password"},
+			env:  map[string]string{"UISCOM_SYNC_TOKEN": "from-env", "UISCOM_SYNC_DB_PASSWORD": "from-env"},
+			want: map[string]string{"t": "from-flag", "w": "from-flag-password", "i": "30", "u": "file-user"},
+		},
+		{
+			name: "env value over env file",
+			env:  map[string]string{"UISCOM_SYNC_DB_USER": "env-user", "UISCOM_SYNC_DB_USER_FILE": "/not/exist"},
+			want: map[string]string{"t": "from-file", "w": "from-secret-file", "i": "30", "u": "env-user"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+			for _, name := range []string{"t", "w", "u"} {
+				fs.String(name, "", "")
+			}
+			fs.Int("i", 10, "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			c, err := loadConfig(configFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := applyConfig(fs, c); err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tt.want {
+				got := fs.Lookup(name).Value.String()
+				if got != want {
+					t.Errorf("-%s %q, expected %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyConfigWrongValue(t *testing.T) {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	fs.Int("i", 10, "")
+	if err := applyConfig(fs, config{values: map[string]any{"interval": "ten"}}); err == nil {
+		t.Error("wrong interval accepted")
+	}
+	if err := applyConfig(fs, config{values: map[string]any{"interval_file": "/not/exist"}}); err == nil {
+		t.Error("missing secret file accepted")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		accounts int
+		wantErr  bool
+	}{
+		{name: "yaml", file: "sync.yml", content: "token: a\naccounts:\n  - name: shop\n    token: b\n", accounts: 1},
+		{name: "toml", file: "sync.toml", content: "token = \"a\"\n[[accounts]]\nname = \"shop\"\ntoken = \"b\"\n", accounts: 1},
+		{name: "unknown key", file: "sync.yaml", content: "tokens: a\n", wantErr: true},
+		{name: "unsupported format", file: "sync.json", content: "{}", wantErr: true},
+		{name: "broken", file: "sync.yaml", content: "token: [", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := loadConfig(writeTestFile(t, tt.file, tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, expected error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(c.accounts) != tt.accounts {
+				t.Errorf("accounts %v, expected %d", c.accounts, tt.accounts)
+			}
+			if _, ok := c.values["accounts"]; ok {
+				t.Error("accounts kept in values")
+			}
+		})
+	}
+}
+
+func TestNewAccounts(t *testing.T) {
+	tokenFile := writeTestFile(t, "token", "secret\n")
+	tests := []struct {
+		name    string
+		configs []accountConfig
+		want    []string
+		wantErr bool
+	}{
+		{name: "single token", want: []string{""}},
+		{name: "accounts", configs: []accountConfig{{Name: "a", Token: "t"}, {Name: "b", TokenFile: tokenFile}}, want: []string{"a", "b"}},
+		{name: "duplicate name", configs: []accountConfig{{Name: "a", Token: "t"}, {Name: "a", Token: "t"}}, wantErr: true},
+		{name: "blank name", configs: []accountConfig{{Token: "t"}}, wantErr: true},
+		{name: "without token", configs: []accountConfig{{Name: "a"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts, err := newAccounts(tt.configs, "token")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, expected error %v", err, tt.wantErr)
+			}
+			if len(accounts) != len(tt.want) {
+				t.Fatalf("accounts %v, expected %v", accounts, tt.want)
+			}
+			for i := range tt.want {
+				if accounts[i].name != tt.want[i] {
+					t.Errorf("account %d name %q, expected %q", i, accounts[i].name, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// job periodic sync task, interval used only in daemon mode
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+// runJobs run all jobs once concurrently
+func runJobs(ctx context.Context, jobs []job) {
+	wg := sync.WaitGroup{}
+	for i := range jobs {
+		wg.Add(1)
+		go func(j job) {
+			defer wg.Done()
+			runJob(ctx, j, nil)
+		}(jobs[i])
+	}
+	wg.Wait()
+}
+
+func runJob(ctx context.Context, j job, h *health) {
+	if verbose {
+		log.Printf("start %s syncing", j.name)
+	}
+	h.start(j.name)
+	err := j.run(ctx)
+	h.finish(j.name, err)
+	stats.report(j.name)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("error %s syncing %s", j.name, err)
+	}
+	if verbose {
+		log.Printf("finish %s syncing", j.name)
+	}
+}
+
+// runDaemon run every job on its own schedule until ctx done,
+// in-flight batches finished before return
+func runDaemon(ctx context.Context, jobs []job, healthAddr string) {
+	h := newHealth(jobs)
+
+	var server *http.Server
+	if healthAddr != "" {
+		server = &http.Server{Addr: healthAddr, Handler: h}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("error health endpoint %s", err)
+			}
+		}()
+	}
+
+	wg := sync.WaitGroup{}
+	for i := range jobs {
+		wg.Add(1)
+		go func(j job) {
+			defer wg.Done()
+			for {
+				runJob(ctx, j, h)
+				timer := time.NewTimer(j.interval)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+		}(jobs[i])
+	}
+	wg.Wait()
+
+	if server != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}
+}
+
+type jobState struct {
+	Interval    string    `json:"interval"`
+	Running     bool      `json:"running"`
+	LastStart   time.Time `json:"last_start,omitempty"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+
+	interval time.Duration
+}
+
+// health jobs state, unhealthy if any job not succeeded during three intervals
+type health struct {
+	mu      sync.Mutex
+	started time.Time
+	jobs    map[string]*jobState
+}
+
+func newHealth(jobs []job) *health {
+	h := &health{
+		started: time.Now(),
+		jobs:    map[string]*jobState{},
+	}
+	for i := range jobs {
+		h.jobs[jobs[i].name] = &jobState{Interval: jobs[i].interval.String(), interval: jobs[i].interval}
+	}
+	return h
+}
+
+func (h *health) start(name string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.jobs[name].Running = true
+	h.jobs[name].LastStart = time.Now()
+}
+
+func (h *health) finish(name string, err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.jobs[name].Running = false
+	if err != nil {
+		h.jobs[name].LastError = err.Error()
+		return
+	}
+	h.jobs[name].LastSuccess = time.Now()
+	h.jobs[name].LastError = ""
+}
+
+func (h *health) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	healthy := true
+	for _, s := range h.jobs {
+		last := s.LastSuccess
+		if last.IsZero() {
+			last = h.started
+		}
+		if time.Since(last) > 3*s.interval {
+			healthy = false
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"healthy": healthy,
+		"jobs":    h.jobs,
+	})
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Supme/uiscom"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+type strictness string
+
+const (
+	// strictAbort any absent or undecodable field abort sync
+	strictAbort = strictness("strict")
+	// strictLenient store NULL for bad fields, rows without key sent to dead letters
+	strictLenient = strictness("lenient")
+)
+
+// decode rows decoding options
+var decode = decodeOptions{strictness: strictAbort}
+
+type decodeOptions struct {
+	strictness  strictness
+	deadLetters *deadLetters
+}
+
+func parseStrictness(s string) (strictness, error) {
+	switch m := strictness(s); m {
+	case strictAbort, strictLenient:
+		return m, nil
+	default:
+		return "", fmt.Errorf("wrong strictness %q, expected %q or %q", s, strictAbort, strictLenient)
+	}
+}
+
+// deadLetter row failed decoding
+type deadLetter struct {
+	Account  string    `json:"account,omitempty"`
+	Entity   string    `json:"entity"`
+	FailedAt time.Time `json:"failed_at"`
+	Error    string    `json:"error"`
+	Row      any       `json:"row"`
+}
+
+// deadLetters rows failed decoding, saved into JSON Lines file if set or dead_letters table
+type deadLetters struct {
+	dbpool *pgxpool.Pool
+
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+func newDeadLetters(dbpool *pgxpool.Pool, filename string) (*deadLetters, error) {
+	d := &deadLetters{dbpool: dbpool}
+	if filename == "" {
+		if dbpool == nil && decode.strictness == strictLenient {
+			return nil, fmt.Errorf("dead letters file required without database")
+		}
+		return d, nil
+	}
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	d.file = f
+	d.enc = json.NewEncoder(f)
+	return d, nil
+}
+
+func (d *deadLetters) add(account, entity string, row any, err error) error {
+	l := deadLetter{Account: account, Entity: entity, FailedAt: time.Now(), Error: err.Error(), Row: row}
+	if d.file != nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.enc.Encode(l)
+	}
+	_, err = d.dbpool.Exec(context.Background(),
+		`INSERT INTO dead_letters (account, entity, failed_at, error, row_data) VALUES ($1, $2, $3, $4, $5)`,
+		l.Account, l.Entity, l.FailedAt, l.Error, l.Row)
+	return err
+}
+
+// stored dead letters have file or database
+func (d *deadLetters) stored() bool {
+	return d.file != nil || d.dbpool != nil
+}
+
+func (d *deadLetters) Close() error {
+	if d.file == nil {
+		return nil
+	}
+	return d.file.Close()
+}
+
+// decodeRows decode report rows by schema, in lenient mode bad fields stored as NULL
+// and rows without key sent to dead letters instead of abort sync
+func decodeRows(a account, entity string, schema uiscom.Schema, data []any) ([]map[string]any, error) {
+	rows := make([]map[string]any, 0, len(data))
+	var nulled, dead int
+	for _, v := range data {
+		if decode.strictness != strictLenient {
+			val, err := schema.DecodeMap(v)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, val)
+			continue
+		}
+
+		val, warnings, err := schema.DecodeMapLenient(v)
+		if err != nil {
+			dead++
+			if err := rejectRow(a, entity, v, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if len(warnings) != 0 {
+			nulled++
+			log.Printf("%s row %v stored with NULL fields: %v", a.entity(entity), val[schema.Columns[0].Name], warnings)
+		}
+		rows = append(rows, val)
+	}
+	stats.add(a.entity(entity), len(rows), nulled, dead)
+	return rows, nil
+}
+
+// rejectRow in lenient mode send row to dead letters, in strict mode return decode error
+func rejectRow(a account, entity string, row any, err error) error {
+	if decode.strictness != strictLenient {
+		return err
+	}
+	log.Printf("%s row sent to dead letters: %s", a.entity(entity), err)
+	if err := decode.deadLetters.add(a.name, entity, row, err); err != nil {
+		return fmt.Errorf("dead letter: %w", err)
+	}
+	return nil
+}
+
+// rejectRecord log not downloaded record and send it to dead letters if they stored
+func rejectRecord(a account, record uiscom.Record, err error) {
+	log.Printf("%s record %s not downloaded: %s", a.entity(entityCalls), record.URL, err)
+	if decode.deadLetters == nil || !decode.deadLetters.stored() {
+		return
+	}
+	if err := decode.deadLetters.add(a.name, "call_records", record, err); err != nil {
+		log.Printf("%s record %s dead letter: %s", a.entity(entityCalls), record.URL, err)
+	}
+}
+
+// decodeCount rows decoded and records downloaded by entity since last report
+type decodeCount struct {
+	rows          int
+	nulled        int
+	dead          int
+	records       int
+	recordsFailed int
+}
+
+// decodeStats rows counts reported after each job run
+type decodeStats struct {
+	mu       sync.Mutex
+	entities map[string]*decodeCount
+}
+
+var stats = &decodeStats{entities: map[string]*decodeCount{}}
+
+func (s *decodeStats) add(entity string, rows, nulled, dead int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.count(entity)
+	c.rows += rows
+	c.nulled += nulled
+	c.dead += dead
+}
+
+func (s *decodeStats) addRecords(entity string, records, failed int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.count(entity)
+	c.records += records
+	c.recordsFailed += failed
+}
+
+func (s *decodeStats) count(entity string) *decodeCount {
+	c, ok := s.entities[entity]
+	if !ok {
+		c = &decodeCount{}
+		s.entities[entity] = c
+	}
+	return c
+}
+
+// report log and reset entity counts
+func (s *decodeStats) report(entity string) {
+	s.mu.Lock()
+	c, ok := s.entities[entity]
+	delete(s.entities, entity)
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	if verbose || c.nulled != 0 || c.dead != 0 {
+		log.Printf("%s rows decoded %d, with NULL fields %d, dead letters %d", entity, c.rows, c.nulled, c.dead)
+	}
+	if (verbose && c.records != 0) || c.recordsFailed != 0 {
+		log.Printf("%s records downloaded %d, failed %d", entity, c.records, c.recordsFailed)
+	}
+}
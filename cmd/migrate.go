@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationsFS versioned schema migrations, file name <version>_<name>.sql,
+// new migration must have the next version and never edited after release
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationsLockID pg_advisory_lock key, one migration process at time
+const migrationsLockID = 7283190145
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// grantOptions optional roles granted on sync tables, blank not granted
+type grantOptions struct {
+	reader string
+	writer string
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []migration
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".sql")
+		versionStr, title, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("wrong migration file name %s", e.Name())
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("wrong migration file name %s: %w", e.Name(), err)
+		}
+		b, err := migrationsFS.ReadFile(path.Join("migrations", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: title, sql: string(b)})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].version)
+		}
+	}
+	return migrations, nil
+}
+
+// migrate apply not applied migrations, each in own transaction, and grant roles
+func migrate(ctx context.Context, dbpool *pgxpool.Pool, grants grantOptions) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	conn, err := dbpool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationsLockID); err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationsLockID)
+
+	_, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS public.schema_migrations
+(
+    version integer NOT NULL,
+    name character varying(250) NOT NULL,
+    applied_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT schema_migrations_pkey PRIMARY KEY (version)
+)`)
+	if err != nil {
+		return err
+	}
+
+	applied := map[int]bool{}
+	rows, err := conn.Query(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		if verbose {
+			log.Printf("apply migration %04d %s", m.version, m.name)
+		}
+		err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, m.sql); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.version, m.name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %04d %s: %w", m.version, m.name, err)
+		}
+	}
+
+	return grant(ctx, conn.Conn(), grants)
+}
+
+// grant reader SELECT and writer ALL on existing and future tables created by current role
+func grant(ctx context.Context, conn *pgx.Conn, grants grantOptions) error {
+	var statements []string
+	if grants.reader != "" {
+		role := pgx.Identifier{grants.reader}.Sanitize()
+		statements = append(statements,
+			`GRANT SELECT ON ALL TABLES IN SCHEMA public TO `+role,
+			`ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT ON TABLES TO `+role,
+		)
+	}
+	if grants.writer != "" {
+		role := pgx.Identifier{grants.writer}.Sanitize()
+		statements = append(statements,
+			`GRANT ALL ON ALL TABLES IN SCHEMA public TO `+role,
+			`ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT ALL ON TABLES TO `+role,
+		)
+	}
+	for _, s := range statements {
+		if _, err := conn.Exec(ctx, s); err != nil {
+			return fmt.Errorf("grant: %w", err)
+		}
+	}
+	return nil
+}
@@ -23,7 +23,7 @@ CREATE TABLE IF NOT EXISTS public.calls
     scenario_name character varying(250) COLLATE pg_catalog."default",
     source character varying(100) COLLATE pg_catalog."default",
     CONSTRAINT calls_pkey PRIMARY KEY (id)
-    )
+    );
 
 -- Table: public.call_legs
 
@@ -63,30 +63,4 @@ CREATE TABLE IF NOT EXISTS public.call_legs
     group_id bigint,
     group_name character varying(250) COLLATE pg_catalog."default",
     CONSTRAINT call_legs_pkey PRIMARY KEY (id)
-    )
-
-
-
-ALTER DEFAULT PRIVILEGES FOR ROLE uiscom
-GRANT SELECT ON TABLES TO uiscom_reader;
-
-ALTER DEFAULT PRIVILEGES FOR ROLE postgres
-GRANT ALL ON TABLES TO uiscom;
-
-ALTER DEFAULT PRIVILEGES FOR ROLE "a.agafonov"
-GRANT SELECT ON TABLES TO uiscom_reader;
-
-ALTER DEFAULT PRIVILEGES FOR ROLE nkozkin
-GRANT SELECT ON TABLES TO uiscom_reader;
-
-ALTER DEFAULT PRIVILEGES FOR ROLE "a.agafonov"
-GRANT ALL ON TABLES TO uiscom;
-
-ALTER DEFAULT PRIVILEGES FOR ROLE postgres
-GRANT SELECT ON TABLES TO uiscom_reader;
-
-ALTER DEFAULT PRIVILEGES FOR ROLE nkozkin
-GRANT ALL ON TABLES TO uiscom;
-
-
-
+    );
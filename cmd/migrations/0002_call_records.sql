@@ -0,0 +1,21 @@
+-- Table: public.call_records
+
+-- DROP TABLE IF EXISTS public.call_records;
+
+CREATE TABLE IF NOT EXISTS public.call_records
+(
+    communication_id bigint NOT NULL,
+    record_id character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    kind character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    variant character varying(20) COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    storage_path character varying(500) COLLATE pg_catalog."default" NOT NULL,
+    storage character varying(20) COLLATE pg_catalog."default" NOT NULL DEFAULT 'primary',
+    size bigint,
+    checksum character varying(64) COLLATE pg_catalog."default",
+    content_type character varying(100) COLLATE pg_catalog."default",
+    duration interval,
+    downloaded_at timestamp without time zone,
+    CONSTRAINT call_records_pkey PRIMARY KEY (communication_id, kind, record_id, variant)
+    );
+
+CREATE INDEX IF NOT EXISTS call_records_storage_path_idx ON public.call_records (storage_path);
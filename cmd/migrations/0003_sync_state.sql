@@ -0,0 +1,11 @@
+-- Table: public.sync_state
+
+-- DROP TABLE IF EXISTS public.sync_state;
+
+CREATE TABLE IF NOT EXISTS public.sync_state
+(
+    entity character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    synced_till timestamp without time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT sync_state_pkey PRIMARY KEY (entity)
+    );
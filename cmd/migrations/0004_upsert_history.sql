@@ -0,0 +1,30 @@
+-- Upsert updated_at
+
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS updated_at timestamp with time zone NOT NULL DEFAULT now();
+ALTER TABLE public.call_legs ADD COLUMN IF NOT EXISTS updated_at timestamp with time zone NOT NULL DEFAULT now();
+
+-- Table: public.calls_history
+
+-- DROP TABLE IF EXISTS public.calls_history;
+
+CREATE TABLE IF NOT EXISTS public.calls_history
+(
+    id bigint NOT NULL,
+    changed_at timestamp with time zone NOT NULL DEFAULT now(),
+    data jsonb NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS calls_history_id_idx ON public.calls_history (id, changed_at);
+
+-- Table: public.call_legs_history
+
+-- DROP TABLE IF EXISTS public.call_legs_history;
+
+CREATE TABLE IF NOT EXISTS public.call_legs_history
+(
+    id bigint NOT NULL,
+    changed_at timestamp with time zone NOT NULL DEFAULT now(),
+    data jsonb NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS call_legs_history_id_idx ON public.call_legs_history (id, changed_at);
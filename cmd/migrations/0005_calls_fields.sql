@@ -0,0 +1,78 @@
+-- Calls report field groups, nested arrays as jsonb
+
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS cpn_region_id bigint;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS cpn_region_name character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS communication_number bigint;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS communication_page_url text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS communication_type character varying(50) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS wait_duration interval;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS total_wait_duration interval;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS lost_call_processing_duration interval;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS talk_duration interval;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS clean_talk_duration interval;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS total_duration interval;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS postprocess_duration interval;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS call_records jsonb;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS wav_call_records jsonb;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS full_record_file_link text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS voice_mail_records jsonb;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS ua_client_id character varying(100) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS ym_client_id character varying(100) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS sale_date timestamp without time zone;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS sale_cost numeric;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS is_transfer boolean;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS search_query text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS search_engine character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS referrer_domain character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS referrer text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS entrance_page text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS gclid character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS yclid character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS ymclid character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS ef_id character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS channel character varying(100) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS last_answered_employee_rating bigint;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS site_domain_name character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS site_id bigint;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS campaign_name character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS campaign_id bigint;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS visit_other_campaign boolean;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS visitor_id bigint;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS person_id bigint;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS visitor_type character varying(50) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS visitor_session_id bigint;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS visits_count bigint;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS visitor_first_campaign_id bigint;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS visitor_first_campaign_name character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS visitor_city character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS visitor_region character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS visitor_country character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS visitor_device character varying(50) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS call_api_request_id character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS call_api_external_id character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS contact_id bigint;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS contact_full_name character varying(250) COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS utm_source text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS utm_medium text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS utm_term text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS utm_content text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS utm_campaign text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS openstat_ad text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS openstat_campaign text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS openstat_service text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS openstat_source text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS eq_utm_source text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS eq_utm_medium text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS eq_utm_term text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS eq_utm_content text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS eq_utm_campaign text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS eq_utm_referrer text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS eq_utm_expid text COLLATE pg_catalog."default";
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS tags jsonb;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS employees jsonb;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS scenario_operations jsonb;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS visitor_custom_properties jsonb;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS segments jsonb;
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS attributes jsonb;
+
+CREATE INDEX IF NOT EXISTS calls_tags_idx ON public.calls USING gin (tags);
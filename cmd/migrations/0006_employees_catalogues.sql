@@ -0,0 +1,118 @@
+-- Table: public.employee_stat
+
+-- DROP TABLE IF EXISTS public.employee_stat;
+
+CREATE TABLE IF NOT EXISTS public.employee_stat
+(
+    employee_id bigint NOT NULL,
+    date_from timestamp without time zone NOT NULL,
+    date_till timestamp without time zone NOT NULL,
+    employee_full_name character varying(250) COLLATE pg_catalog."default",
+    data jsonb,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT employee_stat_pkey PRIMARY KEY (employee_id, date_from)
+    );
+
+CREATE INDEX IF NOT EXISTS employee_stat_date_from_idx ON public.employee_stat (date_from);
+
+-- Table: public.employees
+
+-- DROP TABLE IF EXISTS public.employees;
+
+CREATE TABLE IF NOT EXISTS public.employees
+(
+    id bigint NOT NULL,
+    full_name character varying(250) COLLATE pg_catalog."default",
+    first_name character varying(100) COLLATE pg_catalog."default",
+    last_name character varying(100) COLLATE pg_catalog."default",
+    patronymic character varying(100) COLLATE pg_catalog."default",
+    status_id bigint,
+    email character varying(250) COLLATE pg_catalog."default",
+    extension_phone_number character varying(20) COLLATE pg_catalog."default",
+    phone_numbers jsonb,
+    groups jsonb,
+    deleted_at timestamp with time zone,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT employees_pkey PRIMARY KEY (id)
+    );
+
+-- Table: public.scenarios
+
+-- DROP TABLE IF EXISTS public.scenarios;
+
+CREATE TABLE IF NOT EXISTS public.scenarios
+(
+    id bigint NOT NULL,
+    name character varying(250) COLLATE pg_catalog."default",
+    virtual_phone_numbers jsonb,
+    site_ids jsonb,
+    campaign_ids jsonb,
+    deleted_at timestamp with time zone,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT scenarios_pkey PRIMARY KEY (id)
+    );
+
+-- Table: public.virtual_numbers
+
+-- DROP TABLE IF EXISTS public.virtual_numbers;
+
+CREATE TABLE IF NOT EXISTS public.virtual_numbers
+(
+    id bigint NOT NULL,
+    virtual_phone_number character varying(20) COLLATE pg_catalog."default",
+    activation_date timestamp without time zone,
+    status character varying(50) COLLATE pg_catalog."default",
+    category character varying(50) COLLATE pg_catalog."default",
+    type character varying(50) COLLATE pg_catalog."default",
+    site_id bigint,
+    site_domain_name character varying(250) COLLATE pg_catalog."default",
+    campaign_id bigint,
+    campaign_name character varying(250) COLLATE pg_catalog."default",
+    scenarios jsonb,
+    deleted_at timestamp with time zone,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT virtual_numbers_pkey PRIMARY KEY (id)
+    );
+
+-- Table: public.sites
+
+-- DROP TABLE IF EXISTS public.sites;
+
+CREATE TABLE IF NOT EXISTS public.sites
+(
+    id bigint NOT NULL,
+    domain_name character varying(250) COLLATE pg_catalog."default",
+    default_phone_number character varying(20) COLLATE pg_catalog."default",
+    default_scenario_id bigint,
+    user_id bigint,
+    connected_integrations jsonb,
+    creation_time timestamp without time zone,
+    deleted_at timestamp with time zone,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT sites_pkey PRIMARY KEY (id)
+    );
+
+-- Table: public.campaigns
+
+-- DROP TABLE IF EXISTS public.campaigns;
+
+CREATE TABLE IF NOT EXISTS public.campaigns
+(
+    id bigint NOT NULL,
+    name character varying(250) COLLATE pg_catalog."default",
+    description text COLLATE pg_catalog."default",
+    status character varying(50) COLLATE pg_catalog."default",
+    type character varying(50) COLLATE pg_catalog."default",
+    creation_time timestamp without time zone,
+    site_id bigint,
+    site_domain_name character varying(250) COLLATE pg_catalog."default",
+    costs numeric,
+    cost_ratio numeric,
+    cost_ratio_operator character varying(20) COLLATE pg_catalog."default",
+    engine character varying(100) COLLATE pg_catalog."default",
+    campaign_conditions jsonb,
+    dynamic_call_tracking_settings jsonb,
+    deleted_at timestamp with time zone,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT campaigns_pkey PRIMARY KEY (id)
+    );
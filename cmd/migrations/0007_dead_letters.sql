@@ -0,0 +1,13 @@
+-- Table: public.dead_letters
+
+-- DROP TABLE IF EXISTS public.dead_letters;
+
+CREATE TABLE IF NOT EXISTS public.dead_letters
+(
+    entity character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    failed_at timestamp with time zone NOT NULL DEFAULT now(),
+    error text COLLATE pg_catalog."default" NOT NULL,
+    row_data jsonb
+    );
+
+CREATE INDEX IF NOT EXISTS dead_letters_entity_idx ON public.dead_letters (entity, failed_at);
@@ -0,0 +1,7 @@
+-- Catalogues account, rows soft deleted only inside own account
+
+ALTER TABLE public.employees ADD COLUMN IF NOT EXISTS account character varying(100) COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+ALTER TABLE public.scenarios ADD COLUMN IF NOT EXISTS account character varying(100) COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+ALTER TABLE public.virtual_numbers ADD COLUMN IF NOT EXISTS account character varying(100) COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+ALTER TABLE public.sites ADD COLUMN IF NOT EXISTS account character varying(100) COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+ALTER TABLE public.campaigns ADD COLUMN IF NOT EXISTS account character varying(100) COLLATE pg_catalog."default" NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+-- Account of synced rows, entity of sync_state prefixed by account name
+
+ALTER TABLE public.calls ADD COLUMN IF NOT EXISTS account character varying(100) COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+ALTER TABLE public.call_legs ADD COLUMN IF NOT EXISTS account character varying(100) COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+ALTER TABLE public.employee_stat ADD COLUMN IF NOT EXISTS account character varying(100) COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+ALTER TABLE public.call_records ADD COLUMN IF NOT EXISTS account character varying(100) COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+ALTER TABLE public.dead_letters ADD COLUMN IF NOT EXISTS account character varying(100) COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+
+ALTER TABLE public.sync_state ALTER COLUMN entity TYPE character varying(200);
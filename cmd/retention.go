@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/Supme/uiscom"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type retentionOptions struct {
+	days    int
+	tagDays string
+	cold    string
+	dryRun  bool
+}
+
+// parseTagDays parse "complaint=365,vip=730" into tag retention ages
+func parseTagDays(s string) (map[string]time.Duration, error) {
+	ages := map[string]time.Duration{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		tag, days, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("wrong tag retention %q, expected tag=days", part)
+		}
+		d, err := strconv.Atoi(strings.TrimSpace(days))
+		if err != nil {
+			return nil, fmt.Errorf("wrong tag retention %q: %w", part, err)
+		}
+		ages[strings.TrimSpace(tag)] = time.Duration(d) * 24 * time.Hour
+	}
+	return ages, nil
+}
+
+// runRetention apply retention policy to records indexed in call_records table,
+// tags of calls from calls.tags (kept current by refresh lookback), requested from API for calls not synced
+func runRetention(dbpool *pgxpool.Pool, accounts []account, downloader *uiscom.Downloader, o retentionOptions) error {
+	if downloader == nil {
+		return fmt.Errorf("media storage not configured")
+	}
+	tagMaxAge, err := parseTagDays(o.tagDays)
+	if err != nil {
+		return err
+	}
+	retention := &uiscom.Retention{
+		Storage:   downloader.Storage,
+		Manifest:  downloader.Manifest,
+		MaxAge:    time.Duration(o.days) * 24 * time.Hour,
+		TagMaxAge: tagMaxAge,
+		DryRun:    o.dryRun,
+	}
+	if o.cold != "" {
+		retention.Cold = uiscom.NewLocalStorage(o.cold)
+	}
+
+	now := time.Now()
+	rows, err := dbpool.Query(context.Background(),
+		`SELECT r.account, r.communication_id, c.communication_id IS NOT NULL, r.storage_path, COALESCE(c.start_time, r.downloaded_at),
+		ARRAY(SELECT t->>'tag_name' FROM jsonb_array_elements(COALESCE(c.tags, '[]'::jsonb)) t)
+		FROM call_records r
+		LEFT JOIN calls c ON c.account = r.account AND c.communication_id = r.communication_id
+		WHERE r.storage = 'primary' AND COALESCE(c.start_time, r.downloaded_at) < $1`,
+		now.Add(-retention.MaxAge),
+	)
+	if err != nil {
+		return err
+	}
+	var (
+		records []uiscom.RetentionRecord
+		// notSynced records indexes of calls not in calls table
+		notSynced = map[accountCall][]int{}
+	)
+	for rows.Next() {
+		var (
+			call   accountCall
+			synced bool
+			record uiscom.RetentionRecord
+		)
+		if err := rows.Scan(&call.account, &call.id, &synced, &record.Key, &record.Time, &record.Tags); err != nil {
+			rows.Close()
+			return err
+		}
+		if !synced {
+			notSynced[call] = append(notSynced[call], len(records))
+		}
+		records = append(records, record)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(tagMaxAge) != 0 && len(notSynced) != 0 {
+		lookup := map[string][]uiscom.RetentionRecord{}
+		for call, indexes := range notSynced {
+			for _, i := range indexes {
+				lookup[call.account] = append(lookup[call.account], records[i])
+			}
+		}
+		tags, err := communicationsTags(accounts, lookup)
+		if err != nil {
+			return fmt.Errorf("calls tags: %w", err)
+		}
+		for call, indexes := range notSynced {
+			for _, i := range indexes {
+				records[i].Tags = tags[call]
+			}
+		}
+	}
+
+	var deleted, moved, failed int
+	for _, r := range retention.Apply(context.Background(), records, now) {
+		if r.Err != nil {
+			failed++
+			log.Printf("error retention %s %s: %s", r.Action, r.Record.Key, r.Err)
+			continue
+		}
+		if verbose || o.dryRun {
+			log.Printf("retention %s %s", r.Action, r.Record.Key)
+		}
+		if o.dryRun {
+			continue
+		}
+		switch r.Action {
+		case uiscom.RetentionDelete:
+			deleted++
+			_, err = dbpool.Exec(context.Background(), `DELETE FROM call_records WHERE storage_path = $1`, r.Record.Key)
+		case uiscom.RetentionMove:
+			moved++
+			_, err = dbpool.Exec(context.Background(), `UPDATE call_records SET storage = 'cold', storage_path = $2 WHERE storage_path = $1`, r.Record.Key, r.ColdKey)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	log.Printf("retention: %d records checked, %d deleted, %d moved, %d failed", len(records), deleted, moved, failed)
+	if failed != 0 {
+		return fmt.Errorf("%d records retention failed", failed)
+	}
+	return nil
+}
+
+// accountCall call of account
+type accountCall struct {
+	account string
+	id      int64
+}
+
+// communicationsTagsSchema calls report fields for tags lookup
+var communicationsTagsSchema = uiscom.Schema{
+	Table: "calls",
+	Columns: []uiscom.Column{
+		{Name: "communication_id", Type: uiscom.ColumnInt64, Key: true},
+		{Name: "tags", Type: uiscom.ColumnJSON},
+	},
+}
+
+// communicationsTags tag names of calls started in time range of account records,
+// rows without communication_id skipped
+func communicationsTags(accounts []account, records map[string][]uiscom.RetentionRecord) (map[accountCall][]string, error) {
+	schema := communicationsTagsSchema
+	tags := map[accountCall][]string{}
+	for _, a := range accounts {
+		accountRecords := records[a.name]
+		if len(accountRecords) == 0 {
+			continue
+		}
+		from, till := accountRecords[0].Time, accountRecords[0].Time
+		for i := range accountRecords {
+			if accountRecords[i].Time.Before(from) {
+				from = accountRecords[i].Time
+			}
+			if accountRecords[i].Time.After(till) {
+				till = accountRecords[i].Time
+			}
+		}
+		// calls report period limited, request by month
+		const period = 30 * 24 * time.Hour
+		for start := from.Add(-24 * time.Hour); !start.After(till); start = start.Add(period) {
+			end := start.Add(period)
+			data, err := fetchAll(10000, func(limit, offset int) (any, error) {
+				return a.client.GetCalls(context.Background(), -1, start, end, limit, offset, nil, schema.Fields()...)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", a.entity(entityCalls), err)
+			}
+			for _, v := range data {
+				val, _, err := schema.DecodeMapLenient(v)
+				if err != nil {
+					log.Printf("%s tags row skipped: %s", a.entity(entityCalls), err)
+					continue
+				}
+				call := accountCall{account: a.name, id: val["communication_id"].(int64)}
+				tags[call] = append(tags[call], tagNames(val["tags"])...)
+			}
+		}
+	}
+	return tags, nil
+}
+
+// tagNames tag_name of calls report tags
+func tagNames(v any) []string {
+	var names []string
+	list, _ := v.([]any)
+	for _, t := range list {
+		if m, ok := t.(map[string]any); ok {
+			if name, ok := m["tag_name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTagDays(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{s: "", want: map[string]time.Duration{}},
+		{s: "complaint=365, vip = 730,", want: map[string]time.Duration{"complaint": 365 * 24 * time.Hour, "vip": 730 * 24 * time.Hour}},
+		{s: "complaint", wantErr: true},
+		{s: "complaint=year", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTagDays(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTagDays(%q) error %v, expected error %v", tt.s, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTagDays(%q) = %v, expected %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTagNames(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want []string
+	}{
+		{name: "null", v: nil},
+		{name: "empty", v: []any{}},
+		{
+			name: "tags",
+			v:    []any{map[string]any{"tag_id": 1, "tag_name": "vip"}, map[string]any{"tag_id": 2}, "wrong", map[string]any{"tag_name": "complaint"}},
+			want: []string{"vip", "complaint"},
+		},
+		{name: "wrong type", v: map[string]any{"tag_name": "vip"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tagNames(tt.v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
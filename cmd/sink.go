@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/Supme/uiscom"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xitongsys/parquet-go/writer"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sink synced rows destination, rows values in schema order
+type sink interface {
+	write(s uiscom.Schema, rows [][]any) error
+}
+
+const (
+	sinkPostgres = "postgres"
+	sinkCSV      = "csv"
+	sinkNDJSON   = "ndjson"
+	sinkParquet  = "parquet"
+)
+
+// postgresSink upsert rows into schema table
+type postgresSink struct {
+	dbpool *pgxpool.Pool
+	upsert upsertOptions
+}
+
+func (p postgresSink) write(s uiscom.Schema, rows [][]any) error {
+	return writeRows(p.dbpool, s, rows, p.upsert)
+}
+
+// partitionColumns date partition column of table, rows of other tables partitioned by sync date
+var partitionColumns = map[string]string{
+	"calls":         "start_time",
+	"call_legs":     "start_time",
+	"employee_stat": "date_from",
+}
+
+// fileSink write rows into new file of every date partition
+// <dir>/<table>/date=<YYYY-MM-DD>/<table>-<unix nano>.<format>,
+// files only appended, rows of overlapped sync windows repeated in next files
+type fileSink struct {
+	dir    string
+	format string
+}
+
+func (f fileSink) write(s uiscom.Schema, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	partition := -1
+	for i := range s.Columns {
+		if s.Columns[i].Name == partitionColumns[s.Table] {
+			partition = i
+		}
+	}
+	now := time.Now()
+	partitions := map[string][][]any{}
+	var dates []string
+	for _, row := range rows {
+		date := now
+		if partition >= 0 {
+			if t, ok := row[partition].(time.Time); ok {
+				date = t
+			}
+		}
+		d := date.Format("2006-01-02")
+		if _, ok := partitions[d]; !ok {
+			dates = append(dates, d)
+		}
+		partitions[d] = append(partitions[d], row)
+	}
+
+	for _, d := range dates {
+		name := filepath.Join(f.dir, s.Table, "date="+d, s.Table+"-"+strconv.FormatInt(now.UnixNano(), 10)+"."+f.format)
+		if err := f.writeFile(name, s, partitions[d]); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// writeFile write into temp file and rename, readers never see partial file
+func (f fileSink) writeFile(name string, s uiscom.Schema, rows [][]any) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	switch f.format {
+	case sinkCSV:
+		err = writeCSV(tmp, s, rows)
+	case sinkNDJSON:
+		err = writeNDJSON(tmp, s, rows)
+	case sinkParquet:
+		err = writeParquet(tmp, s, rows)
+	default:
+		err = fmt.Errorf("unsupported format %q", f.format)
+	}
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func writeCSV(w io.Writer, s uiscom.Schema, rows [][]any) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(s.ColumnNames()); err != nil {
+		return err
+	}
+	record := make([]string, len(s.Columns))
+	for _, row := range rows {
+		for i, c := range s.Columns {
+			v, err := fileValue(c, row[i])
+			if err != nil {
+				return err
+			}
+			switch v := v.(type) {
+			case nil:
+				record[i] = ""
+			case string:
+				record[i] = v
+			case json.RawMessage:
+				record[i] = string(v)
+			case time.Time:
+				record[i] = uiscom.TimeToString(v)
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeNDJSON(w io.Writer, s uiscom.Schema, rows [][]any) error {
+	enc := json.NewEncoder(w)
+	for _, row := range rows {
+		m := make(map[string]any, len(s.Columns))
+		for i, c := range s.Columns {
+			v, err := fileValue(c, row[i])
+			if err != nil {
+				return err
+			}
+			if t, ok := v.(time.Time); ok {
+				v = uiscom.TimeToString(t)
+			}
+			m[c.Name] = v
+		}
+		if err := enc.Encode(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeParquet(w io.Writer, s uiscom.Schema, rows [][]any) error {
+	md := make([]string, len(s.Columns))
+	for i, c := range s.Columns {
+		var t string
+		switch c.Type {
+		case uiscom.ColumnInt64, uiscom.ColumnDuration:
+			t = "type=INT64"
+		case uiscom.ColumnFloat64:
+			t = "type=DOUBLE"
+		case uiscom.ColumnBool:
+			t = "type=BOOLEAN"
+		case uiscom.ColumnTime:
+			t = "type=INT64, convertedtype=TIMESTAMP_MILLIS"
+		default:
+			t = "type=BYTE_ARRAY, convertedtype=UTF8"
+		}
+		repetition := "OPTIONAL"
+		if c.Key || c.NotNull {
+			repetition = "REQUIRED"
+		}
+		md[i] = "name=" + c.Name + ", " + t + ", repetitiontype=" + repetition
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(md, w, 1)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		// writer keep records until flush, so record not reused
+		record := make([]any, len(s.Columns))
+		for i, c := range s.Columns {
+			v, err := fileValue(c, row[i])
+			if err != nil {
+				return err
+			}
+			switch v := v.(type) {
+			case json.RawMessage:
+				record[i] = string(v)
+			case time.Time:
+				record[i] = v.UnixMilli()
+			default:
+				record[i] = v
+			}
+		}
+		if err := pw.Write(record); err != nil {
+			return err
+		}
+	}
+	return pw.WriteStop()
+}
+
+// fileValue column value for file formats: nil, int64, float64, bool, string,
+// time.Time, duration in seconds, json.RawMessage
+func fileValue(c uiscom.Column, v any) (any, error) {
+	if c.Type == uiscom.ColumnJSON {
+		if v == nil {
+			return nil, nil
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Name, err)
+		}
+		if bytes.Equal(b, []byte("null")) {
+			return nil, nil
+		}
+		return json.RawMessage(b), nil
+	}
+
+	// catalogue rows have nullable pointer fields
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, nil
+	}
+
+	switch c.Type {
+	case uiscom.ColumnTime:
+		t, ok := rv.Interface().(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("%s: wrong type %T", c.Name, v)
+		}
+		return t, nil
+	case uiscom.ColumnDuration:
+		d, ok := rv.Interface().(time.Duration)
+		if !ok {
+			return nil, fmt.Errorf("%s: wrong type %T", c.Name, v)
+		}
+		return int64(d / time.Second), nil
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		return rv.String(), nil
+	default:
+		return nil, fmt.Errorf("%s: wrong type %T", c.Name, v)
+	}
+}
+
+// sinkOptions entities sinks, per entity overrides like "calls=parquet,catalogues=csv"
+type sinkOptions struct {
+	defaultSink string
+	entities    string
+	dir         string
+}
+
+// parseSinks parse entity sinks overrides
+func parseSinks(s string) (map[string]string, error) {
+	sinks := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		entity, kind, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("wrong entity sink %q, expected entity=sink", part)
+		}
+		entity = strings.TrimSpace(entity)
+		switch entity {
+		case entityCalls, entityCallLegs, entityEmployeeStat, entityCatalogues:
+		default:
+			return nil, fmt.Errorf("wrong entity sink %q, unknown entity %q", part, entity)
+		}
+		sinks[entity] = strings.TrimSpace(kind)
+	}
+	return sinks, nil
+}
+
+// entitySinks sink of every entity, nil dbpool allowed only if all sinks are files
+func entitySinks(o sinkOptions, dbpool *pgxpool.Pool) (map[string]sink, error) {
+	overrides, err := parseSinks(o.entities)
+	if err != nil {
+		return nil, err
+	}
+	sinks := map[string]sink{}
+	for _, entity := range []string{entityCalls, entityCallLegs, entityEmployeeStat, entityCatalogues} {
+		kind, ok := overrides[entity]
+		if !ok {
+			kind = o.defaultSink
+		}
+		switch kind {
+		case sinkPostgres:
+			if dbpool == nil {
+				return nil, fmt.Errorf("%s: %s sink need database", entity, kind)
+			}
+			u := upsertOptions{mode: upsertUpdate}
+			if entity == entityCalls || entity == entityCallLegs {
+				u = upsert
+			}
+			sinks[entity] = postgresSink{dbpool: dbpool, upsert: u}
+		case sinkCSV, sinkNDJSON, sinkParquet:
+			if o.dir == "" {
+				return nil, fmt.Errorf("%s: %s sink need output folder", entity, kind)
+			}
+			sinks[entity] = fileSink{dir: o.dir, format: kind}
+		default:
+			return nil, fmt.Errorf("%s: unknown sink %q, expected %s, %s, %s or %s", entity, kind, sinkPostgres, sinkCSV, sinkNDJSON, sinkParquet)
+		}
+	}
+	return sinks, nil
+}
+
+// needDatabase any entity sink is postgres
+func (o sinkOptions) needDatabase() (bool, error) {
+	overrides, err := parseSinks(o.entities)
+	if err != nil {
+		return false, err
+	}
+	for _, entity := range []string{entityCalls, entityCallLegs, entityEmployeeStat, entityCatalogues} {
+		kind, ok := overrides[entity]
+		if !ok {
+			kind = o.defaultSink
+		}
+		if kind == sinkPostgres {
+			return true, nil
+		}
+	}
+	return false, nil
+}
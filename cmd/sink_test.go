@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/Supme/uiscom"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var sinkTestSchema = uiscom.Schema{
+	Table: "calls",
+	Columns: []uiscom.Column{
+		{Name: "id", Type: uiscom.ColumnInt64, Key: true},
+		{Name: "start_time", Type: uiscom.ColumnTime},
+		{Name: "name", Type: uiscom.ColumnString},
+		{Name: "cost", Type: uiscom.ColumnFloat64},
+		{Name: "is_lost", Type: uiscom.ColumnBool},
+		{Name: "talk_duration", Type: uiscom.ColumnDuration},
+		{Name: "tags", Type: uiscom.ColumnJSON},
+	},
+}
+
+func sinkTestRows() [][]any {
+	name := "catalogue"
+	return [][]any{
+		{int64(1), time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local), "first", 1.5, true, 30 * time.Second, []any{map[string]any{"tag_name": "vip"}}},
+		{int64(2), time.Date(2024, 3, 2, 11, 0, 0, 0, time.Local), &name, nil, false, nil, nil},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeCSV(&b, sinkTestSchema, sinkTestRows()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"id", "start_time", "name", "cost", "is_lost", "talk_duration", "tags"},
+		{"1", "2024-03-01 10:00:00", "first", "1.5", "true", "30", `[{"tag_name":"vip"}]`},
+		{"2", "2024-03-02 11:00:00", "catalogue", "", "false", "", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeNDJSON(&b, sinkTestSchema, sinkTestRows()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	want := []map[string]any{
+		{"id": 1.0, "start_time": "2024-03-01 10:00:00", "name": "first", "cost": 1.5, "is_lost": true, "talk_duration": 30.0, "tags": []any{map[string]any{"tag_name": "vip"}}},
+		{"id": 2.0, "start_time": "2024-03-02 11:00:00", "name": "catalogue", "cost": nil, "is_lost": false, "talk_duration": nil, "tags": nil},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("lines %q, expected %d", lines, len(want))
+	}
+	for i := range lines {
+		var got map[string]any
+		if err := json.Unmarshal([]byte(lines[i]), &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("line %d got %v, expected %v", i, got, want[i])
+		}
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeParquet(&b, sinkTestSchema, sinkTestRows()); err != nil {
+		t.Fatal(err)
+	}
+	f, err := buffer.NewBufferFile(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := reader.NewParquetReader(f, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.ReadStop()
+	if n := pr.GetNumRows(); n != 2 {
+		t.Fatalf("rows %d, expected 2", n)
+	}
+	rows, err := pr.ReadByNumber(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if err := json.NewEncoder(&b).Encode(rows); err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"Id":1,"Start_time":` + strconv.FormatInt(time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local).UnixMilli(), 10) +
+		`,"Name":"first","Cost":1.5,"Is_lost":true,"Talk_duration":30,"Tags":"[{\"tag_name\":\"vip\"}]"},` +
+		`{"Id":2,"Start_time":` + strconv.FormatInt(time.Date(2024, 3, 2, 11, 0, 0, 0, time.Local).UnixMilli(), 10) +
+		`,"Name":"catalogue","Cost":null,"Is_lost":false,"Talk_duration":null,"Tags":null}]` + "\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %s, expected %s", got, want)
+	}
+}
+
+func TestFileSinkPartitions(t *testing.T) {
+	dir := t.TempDir()
+	if err := (fileSink{dir: dir, format: sinkNDJSON}).write(sinkTestSchema, sinkTestRows()); err != nil {
+		t.Fatal(err)
+	}
+	for _, date := range []string{"2024-03-01", "2024-03-02"} {
+		files, err := filepath.Glob(filepath.Join(dir, "calls", "date="+date, "calls-*.ndjson"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 1 {
+			t.Errorf("partition %s files %v, expected one file", date, files)
+		}
+	}
+	temp, _ := filepath.Glob(filepath.Join(dir, "calls", "*", ".tmp-*"))
+	if len(temp) != 0 {
+		t.Errorf("temp files left: %v", temp)
+	}
+}
+
+func TestEntitySinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		o       sinkOptions
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "files",
+			o:    sinkOptions{defaultSink: sinkCSV, entities: "calls=parquet, catalogues=ndjson", dir: "out"},
+			want: map[string]string{entityCalls: sinkParquet, entityCallLegs: sinkCSV, entityEmployeeStat: sinkCSV, entityCatalogues: sinkNDJSON},
+		},
+		{name: "postgres without database", o: sinkOptions{defaultSink: sinkCSV, entities: "calls=postgres", dir: "out"}, wantErr: true},
+		{name: "file without folder", o: sinkOptions{defaultSink: sinkCSV}, wantErr: true},
+		{name: "unknown sink", o: sinkOptions{defaultSink: "xml", dir: "out"}, wantErr: true},
+		{name: "unknown entity", o: sinkOptions{defaultSink: sinkCSV, entities: "chats=csv", dir: "out"}, wantErr: true},
+		{name: "wrong override", o: sinkOptions{defaultSink: sinkCSV, entities: "calls", dir: "out"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sinks, err := entitySinks(tt.o, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, expected error %v", err, tt.wantErr)
+			}
+			for entity, format := range tt.want {
+				if s, ok := sinks[entity].(fileSink); !ok || s.format != format {
+					t.Errorf("%s sink %#v, expected %s file sink", entity, sinks[entity], format)
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+			need, err := tt.o.needDatabase()
+			if err != nil || need {
+				t.Errorf("need database %v, %v, expected false", need, err)
+			}
+		})
+	}
+}
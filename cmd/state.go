@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Supme/uiscom"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	entityCalls    = "calls"
+	entityCallLegs = "call_legs"
+	// entityEmployeeStat synced by hours
+	entityEmployeeStat = "employee_stat"
+	// entityCatalogues employees, scenarios, virtual_numbers, sites and campaigns, full refresh without checkpoint
+	entityCatalogues = "catalogues"
+)
+
+// account synced account, name prefix entities of jobs, checkpoints and dead letters
+type account struct {
+	name   string
+	client *uiscom.Client
+}
+
+// entity account entity name, entity itself for unnamed account
+func (a account) entity(entity string) string {
+	if a.name == "" {
+		return entity
+	}
+	return a.name + "/" + entity
+}
+
+// accountColumn account name column of synced tables, blank for unnamed account
+var accountColumn = uiscom.Column{Name: "account", Type: uiscom.ColumnString, Size: 100}
+
+// withAccount report schema with account column appended
+func withAccount(s uiscom.Schema) uiscom.Schema {
+	columns := make([]uiscom.Column, 0, len(s.Columns)+1)
+	s.Columns = append(append(columns, s.Columns...), accountColumn)
+	return s
+}
+
+// syncWindow sync window options
+type syncWindow struct {
+	from, till time.Time
+	// explicit window from -f flag, checkpoint not used for window start
+	explicit bool
+	// overlap step back from checkpoint for late arriving data
+	overlap time.Duration
+	// refresh re-synced period before window end for rows changed later than overlap
+	refresh time.Duration
+	// chunk max window of one batch, checkpoint advanced after each batch
+	chunk time.Duration
+}
+
+// checkpoints entities last successfully synced time
+type checkpoints interface {
+	// load checkpoint of entity, false if not synced yet
+	load(entity string) (time.Time, bool, error)
+	// save advance entity checkpoint, never move it back
+	save(entity string, till time.Time) error
+}
+
+// postgresCheckpoints checkpoints in sync_state table
+type postgresCheckpoints struct {
+	dbpool *pgxpool.Pool
+}
+
+func (p postgresCheckpoints) load(entity string) (time.Time, bool, error) {
+	return loadCheckpoint(p.dbpool, entity)
+}
+
+func (p postgresCheckpoints) save(entity string, till time.Time) error {
+	return saveCheckpoint(p.dbpool, entity, till)
+}
+
+// fileCheckpoints checkpoints in JSON file for file sinks without database
+type fileCheckpoints struct {
+	filename string
+
+	mu    sync.Mutex
+	state map[string]time.Time
+}
+
+func newFileCheckpoints(filename string) (*fileCheckpoints, error) {
+	f := &fileCheckpoints{filename: filename, state: map[string]time.Time{}}
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &f.state); err != nil {
+		return nil, fmt.Errorf("sync state %s: %w", filename, err)
+	}
+	return f, nil
+}
+
+func (f *fileCheckpoints) load(entity string) (time.Time, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	till, ok := f.state[entity]
+	return till.Local(), ok, nil
+}
+
+// save write whole state into temp file and rename, state not lost on crash while writing
+func (f *fileCheckpoints) save(entity string, till time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !till.After(f.state[entity]) {
+		return nil
+	}
+	f.state[entity] = till
+	b, err := json.MarshalIndent(f.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.filename), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.filename), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.filename)
+}
+
+// loadCheckpoint last successfully synced time of entity, false if not synced yet
+func loadCheckpoint(dbpool *pgxpool.Pool, entity string) (time.Time, bool, error) {
+	var till time.Time
+	err := dbpool.QueryRow(context.Background(),
+		`SELECT synced_till FROM sync_state WHERE entity = $1`, entity).Scan(&till)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	// timestamp without time zone scanned as UTC, API times are local
+	return time.Date(till.Year(), till.Month(), till.Day(), till.Hour(), till.Minute(), till.Second(), till.Nanosecond(), time.Local), true, nil
+}
+
+// saveCheckpoint advance entity checkpoint, never move it back
+func saveCheckpoint(dbpool *pgxpool.Pool, entity string, till time.Time) error {
+	_, err := dbpool.Exec(context.Background(),
+		`INSERT INTO sync_state (entity, synced_till, updated_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (entity) DO UPDATE SET
+		synced_till = GREATEST(sync_state.synced_till, EXCLUDED.synced_till),
+		updated_at = EXCLUDED.updated_at`,
+		entity, till)
+	return err
+}
+
+// syncEntity sync entity by batches from checkpoint (or window start) till window end,
+// checkpoint advanced only after batch fully synced, stop between batches when ctx done
+func syncEntity(ctx context.Context, state checkpoints, entity string, w syncWindow, sync func(from, till time.Time) error) error {
+	from := w.from
+	if !w.explicit {
+		checkpoint, ok, err := state.load(entity)
+		if err != nil {
+			return fmt.Errorf("load %s checkpoint: %w", entity, err)
+		}
+		if ok {
+			from = checkpoint.Add(-w.overlap)
+		}
+	}
+	if !w.explicit && w.refresh > 0 && w.till.Add(-w.refresh).Before(from) {
+		from = w.till.Add(-w.refresh)
+	}
+	if !from.Before(w.till) {
+		return nil
+	}
+
+	chunk := w.chunk
+	if chunk <= 0 {
+		chunk = w.till.Sub(from)
+	}
+	for start := from; start.Before(w.till); {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := start.Add(chunk)
+		if end.After(w.till) {
+			end = w.till
+		}
+		if verbose {
+			log.Printf("%s syncing between \"%s\" and \"%s\"", entity, uiscom.TimeToString(start), uiscom.TimeToString(end))
+		}
+		if err := sync(start, end); err != nil {
+			return err
+		}
+		if err := state.save(entity, end); err != nil {
+			return fmt.Errorf("save %s checkpoint: %w", entity, err)
+		}
+		start = end
+	}
+	return nil
+}
+
+// fetchAll request report pages until page shorter than limit
+func fetchAll(limit int, fetch func(limit, offset int) (any, error)) ([]any, error) {
+	var rows []any
+	for offset := 0; ; offset += limit {
+		resp, err := fetch(limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		m, _ := resp.(map[string]any)
+		data, ok := m["data"].([]any)
+		if !ok {
+			return nil, fmt.Errorf("response not have field 'data'")
+		}
+		rows = append(rows, data...)
+		if len(data) < limit {
+			return rows, nil
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// memCheckpoints checkpoints in memory
+type memCheckpoints map[string]time.Time
+
+func (m memCheckpoints) load(entity string) (time.Time, bool, error) {
+	till, ok := m[entity]
+	return till, ok, nil
+}
+
+func (m memCheckpoints) save(entity string, till time.Time) error {
+	if till.After(m[entity]) {
+		m[entity] = till
+	}
+	return nil
+}
+
+type syncCall struct {
+	from, till time.Time
+}
+
+func TestSyncEntity(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, 3, 1, hour, 0, 0, 0, time.Local)
+	}
+	tests := []struct {
+		name       string
+		checkpoint *time.Time
+		w          syncWindow
+		want       []syncCall
+	}{
+		{
+			name: "first sync from window start",
+			w:    syncWindow{from: at(0), till: at(10)},
+			want: []syncCall{{at(0), at(10)}},
+		},
+		{
+			name:       "from checkpoint with overlap",
+			checkpoint: ptrTime(at(6)),
+			w:          syncWindow{from: at(0), till: at(10), overlap: time.Hour},
+			want:       []syncCall{{at(5), at(10)}},
+		},
+		{
+			name:       "explicit window ignore checkpoint",
+			checkpoint: ptrTime(at(6)),
+			w:          syncWindow{from: at(1), till: at(10), overlap: time.Hour, explicit: true},
+			want:       []syncCall{{at(1), at(10)}},
+		},
+		{
+			name:       "refresh lookback before checkpoint",
+			checkpoint: ptrTime(at(9)),
+			w:          syncWindow{from: at(0), till: at(10), overlap: time.Hour, refresh: 4 * time.Hour},
+			want:       []syncCall{{at(6), at(10)}},
+		},
+		{
+			name:       "refresh lookback shorter than overlap",
+			checkpoint: ptrTime(at(9)),
+			w:          syncWindow{from: at(0), till: at(10), overlap: 3 * time.Hour, refresh: time.Hour},
+			want:       []syncCall{{at(6), at(10)}},
+		},
+		{
+			name:       "explicit window ignore refresh",
+			checkpoint: ptrTime(at(9)),
+			w:          syncWindow{from: at(8), till: at(10), refresh: 4 * time.Hour, explicit: true},
+			want:       []syncCall{{at(8), at(10)}},
+		},
+		{
+			name: "chunks",
+			w:    syncWindow{from: at(0), till: at(10), chunk: 4 * time.Hour},
+			want: []syncCall{{at(0), at(4)}, {at(4), at(8)}, {at(8), at(10)}},
+		},
+		{
+			name:       "checkpoint at window end",
+			checkpoint: ptrTime(at(10)),
+			w:          syncWindow{from: at(0), till: at(10)},
+		},
+		{
+			name: "empty window",
+			w:    syncWindow{from: at(10), till: at(10)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := memCheckpoints{}
+			if tt.checkpoint != nil {
+				state["calls"] = *tt.checkpoint
+			}
+			var got []syncCall
+			err := syncEntity(context.Background(), state, "calls", tt.w, func(from, till time.Time) error {
+				got = append(got, syncCall{from, till})
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("synced %v, expected %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if !got[i].from.Equal(tt.want[i].from) || !got[i].till.Equal(tt.want[i].till) {
+					t.Errorf("synced %v, expected %v", got, tt.want)
+				}
+			}
+			if len(tt.want) != 0 && !state["calls"].Equal(tt.w.till) {
+				t.Errorf("checkpoint %s, expected %s", state["calls"], tt.w.till)
+			}
+		})
+	}
+}
+
+func TestSyncEntityFailedChunk(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	state := memCheckpoints{}
+	w := syncWindow{from: start, till: start.Add(10 * time.Hour), chunk: 4 * time.Hour}
+	failed := errors.New("failed")
+	var calls int
+	err := syncEntity(context.Background(), state, "calls", w, func(from, till time.Time) error {
+		calls++
+		if calls == 2 {
+			return failed
+		}
+		return nil
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("error %v, expected %v", err, failed)
+	}
+	// checkpoint advanced by first chunk only, failed chunk synced again next run
+	if want := start.Add(4 * time.Hour); !state["calls"].Equal(want) {
+		t.Errorf("checkpoint %s, expected %s", state["calls"], want)
+	}
+}
+
+func TestSyncEntityCanceled(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	ctx, cancel := context.WithCancel(context.Background())
+	w := syncWindow{from: start, till: start.Add(10 * time.Hour), chunk: 4 * time.Hour}
+	var calls int
+	err := syncEntity(ctx, memCheckpoints{}, "calls", w, func(from, till time.Time) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("error %v after %d chunks, expected canceled after 1", err, calls)
+	}
+}
+
+func TestAccountEntity(t *testing.T) {
+	if got := (account{}).entity(entityCalls); got != "calls" {
+		t.Errorf("unnamed account entity %q, expected calls", got)
+	}
+	if got := (account{name: "shop"}).entity(entityCalls); got != "shop/calls" {
+		t.Errorf("account entity %q, expected shop/calls", got)
+	}
+}
+
+func TestFetchAll(t *testing.T) {
+	var offsets []int
+	rows, err := fetchAll(2, func(limit, offset int) (any, error) {
+		offsets = append(offsets, offset)
+		data := []any{1, 2}
+		if offset == 4 {
+			data = data[:1]
+		}
+		return map[string]any{"data": data}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 || len(offsets) != 3 || offsets[2] != 4 {
+		t.Errorf("rows %v by offsets %v, expected 5 rows by 3 pages", rows, offsets)
+	}
+
+	if _, err := fetchAll(2, func(limit, offset int) (any, error) {
+		return map[string]any{}, nil
+	}); err == nil {
+		t.Error("response without data not error")
+	}
+}
+
+func TestFileCheckpoints(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state", "sync_state.json")
+	f, err := newFileCheckpoints(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := f.load("calls"); ok || err != nil {
+		t.Fatalf("checkpoint found %v, %v in new state", ok, err)
+	}
+	till := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+	if err := f.save("calls", till); err != nil {
+		t.Fatal(err)
+	}
+	// checkpoint never moved back
+	if err := f.save("calls", till.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = newFileCheckpoints(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := f.load("calls")
+	if err != nil || !ok || !got.Equal(till) {
+		t.Errorf("checkpoint %s, %v, %v, expected %s", got, ok, err, till)
+	}
+
+	if err := os.WriteFile(filename, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newFileCheckpoints(filename); err == nil {
+		t.Error("broken state file not error")
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/Supme/uiscom"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"strings"
+	"time"
+)
+
+type upsertMode string
+
+const (
+	// upsertNothing keep first synced row
+	upsertNothing = upsertMode("nothing")
+	// upsertUpdate update changed columns and updated_at
+	upsertUpdate = upsertMode("update")
+)
+
+type upsertOptions struct {
+	mode upsertMode
+	// history save previous row version into <table>_history before update
+	history bool
+}
+
+var upsert = upsertOptions{mode: upsertUpdate}
+
+func parseUpsertMode(s string) (upsertMode, error) {
+	switch m := upsertMode(s); m {
+	case upsertNothing, upsertUpdate:
+		return m, nil
+	default:
+		return "", fmt.Errorf("wrong upsert mode %q, expected %q or %q", s, upsertNothing, upsertUpdate)
+	}
+}
+
+// stagingTable temporary table of written rows, dropped on commit
+func stagingTable(s uiscom.Schema) string {
+	return s.Table + "_staging"
+}
+
+// stagingSQL create temporary table with schema columns and row number,
+// later row of the same key wins
+func stagingSQL(s uiscom.Schema) string {
+	return "CREATE TEMP TABLE " + stagingTable(s) + " ON COMMIT DROP AS\n" +
+		"SELECT " + strings.Join(s.ColumnNames(), ", ") + ", 0::bigint AS staging_row FROM " + s.Table + " WITH NO DATA"
+}
+
+// upsertSQL insert statement of staging table rows
+func upsertSQL(s uiscom.Schema, o upsertOptions) string {
+	table, staging, columns, keys := s.Table, stagingTable(s), s.ColumnNames(), s.Keys()
+	key := strings.Join(columns[:keys], ", ")
+	// ON CONFLICT DO UPDATE can not affect one row twice, so last row of every key
+	insert := "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ")\n" +
+		"SELECT DISTINCT ON (" + key + ") " + strings.Join(columns, ", ") + " FROM " + staging + "\n" +
+		"ORDER BY " + key + ", staging_row DESC\n"
+
+	if o.mode != upsertUpdate {
+		return insert + "ON CONFLICT DO NOTHING"
+	}
+
+	set := make([]string, 0, len(columns))
+	old := make([]string, 0, len(columns))
+	excluded := make([]string, 0, len(columns))
+	for _, c := range columns[keys:] {
+		set = append(set, c+" = EXCLUDED."+c)
+		old = append(old, table+"."+c)
+		excluded = append(excluded, "EXCLUDED."+c)
+	}
+	set = append(set, "updated_at = now()")
+	insert += "ON CONFLICT (" + key + ") DO UPDATE SET\n" + strings.Join(set, ",\n") + "\n" +
+		"WHERE (" + strings.Join(old, ", ") + ") IS DISTINCT FROM (" + strings.Join(excluded, ", ") + ")"
+
+	if !o.history {
+		return insert
+	}
+	// all CTE parts see the table before statement, so old is previous row version
+	return "WITH old AS (SELECT * FROM " + table + " WHERE (" + key + ") IN (SELECT " + key + " FROM " + staging + ")),\n" +
+		"upserted AS (" + insert + "\nRETURNING " + key + ")\n" +
+		"INSERT INTO " + table + "_history (" + key + ", changed_at, data)\n" +
+		"SELECT old." + strings.Join(columns[:keys], ", old.") + ", now(), to_jsonb(old) FROM old JOIN upserted USING (" + key + ")"
+}
+
+// writeRows upsert schema rows in one transaction, rows copied into staging table
+// and upserted by one statement, so window rows written all or none
+func writeRows(dbpool *pgxpool.Pool, s uiscom.Schema, rows [][]any, o upsertOptions) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	columns := append(s.ColumnNames(), "staging_row")
+	err := pgx.BeginFunc(context.Background(), dbpool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(context.Background(), stagingSQL(s)); err != nil {
+			return err
+		}
+		_, err := tx.CopyFrom(context.Background(), pgx.Identifier{stagingTable(s)}, columns, pgx.CopyFromSlice(len(rows), func(i int) ([]any, error) {
+			return append(pgValues(rows[i]), int64(i)), nil
+		}))
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(context.Background(), upsertSQL(s, o))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("write %s %d rows: %w", s.Table, len(rows), err)
+	}
+	return nil
+}
+
+// pgValues row values encodable by pgx, durations as interval
+func pgValues(values []any) []any {
+	for i := range values {
+		if d, ok := values[i].(time.Duration); ok {
+			values[i] = durationToInterval(d)
+		}
+	}
+	return values
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"github.com/Supme/uiscom"
+	"github.com/jackc/pgx/v5/pgtype"
+	"testing"
+	"time"
+)
+
+var upsertTestSchema = uiscom.Schema{
+	Table: "calls",
+	Columns: []uiscom.Column{
+		{Name: "id", Type: uiscom.ColumnInt64, Key: true},
+		{Name: "name", Type: uiscom.ColumnString, Size: 100},
+		{Name: "talk", Type: uiscom.ColumnInt64},
+	},
+}
+
+func TestUpsertSQL(t *testing.T) {
+	const insert = "INSERT INTO calls (id, name, talk)\n" +
+		"SELECT DISTINCT ON (id) id, name, talk FROM calls_staging\n" +
+		"ORDER BY id, staging_row DESC\n"
+	const update = insert +
+		"ON CONFLICT (id) DO UPDATE SET\n" +
+		"name = EXCLUDED.name,\n" +
+		"talk = EXCLUDED.talk,\n" +
+		"updated_at = now()\n" +
+		"WHERE (calls.name, calls.talk) IS DISTINCT FROM (EXCLUDED.name, EXCLUDED.talk)"
+	tests := []struct {
+		name string
+		o    upsertOptions
+		want string
+	}{
+		{
+			name: "nothing",
+			o:    upsertOptions{mode: upsertNothing},
+			want: insert + "ON CONFLICT DO NOTHING",
+		},
+		{
+			name: "nothing ignore history",
+			o:    upsertOptions{mode: upsertNothing, history: true},
+			want: insert + "ON CONFLICT DO NOTHING",
+		},
+		{
+			name: "update",
+			o:    upsertOptions{mode: upsertUpdate},
+			want: update,
+		},
+		{
+			name: "update with history",
+			o:    upsertOptions{mode: upsertUpdate, history: true},
+			want: "WITH old AS (SELECT * FROM calls WHERE (id) IN (SELECT id FROM calls_staging)),\n" +
+				"upserted AS (" + update + "\nRETURNING id)\n" +
+				"INSERT INTO calls_history (id, changed_at, data)\n" +
+				"SELECT old.id, now(), to_jsonb(old) FROM old JOIN upserted USING (id)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := upsertSQL(upsertTestSchema, tt.o); got != tt.want {
+				t.Errorf("got\n%s\nexpected\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpsertSQLCompositeKey(t *testing.T) {
+	s := uiscom.Schema{
+		Table: "employee_stat",
+		Columns: []uiscom.Column{
+			{Name: "employee_id", Type: uiscom.ColumnInt64, Key: true},
+			{Name: "date_from", Type: uiscom.ColumnTime, Key: true},
+			{Name: "calls", Type: uiscom.ColumnInt64},
+		},
+	}
+	want := "WITH old AS (SELECT * FROM employee_stat WHERE (employee_id, date_from) IN (SELECT employee_id, date_from FROM employee_stat_staging)),\n" +
+		"upserted AS (INSERT INTO employee_stat (employee_id, date_from, calls)\n" +
+		"SELECT DISTINCT ON (employee_id, date_from) employee_id, date_from, calls FROM employee_stat_staging\n" +
+		"ORDER BY employee_id, date_from, staging_row DESC\n" +
+		"ON CONFLICT (employee_id, date_from) DO UPDATE SET\n" +
+		"calls = EXCLUDED.calls,\n" +
+		"updated_at = now()\n" +
+		"WHERE (employee_stat.calls) IS DISTINCT FROM (EXCLUDED.calls)\n" +
+		"RETURNING employee_id, date_from)\n" +
+		"INSERT INTO employee_stat_history (employee_id, date_from, changed_at, data)\n" +
+		"SELECT old.employee_id, old.date_from, now(), to_jsonb(old) FROM old JOIN upserted USING (employee_id, date_from)"
+	if got := upsertSQL(s, upsertOptions{mode: upsertUpdate, history: true}); got != want {
+		t.Errorf("got\n%s\nexpected\n%s", got, want)
+	}
+}
+
+func TestStagingSQL(t *testing.T) {
+	want := "CREATE TEMP TABLE calls_staging ON COMMIT DROP AS\n" +
+		"SELECT id, name, talk, 0::bigint AS staging_row FROM calls WITH NO DATA"
+	if got := stagingSQL(upsertTestSchema); got != want {
+		t.Errorf("got\n%s\nexpected\n%s", got, want)
+	}
+}
+
+func TestParseUpsertMode(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    upsertMode
+		wantErr bool
+	}{
+		{s: "nothing", want: upsertNothing},
+		{s: "update", want: upsertUpdate},
+		{s: "replace", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseUpsertMode(tt.s)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseUpsertMode(%q) = %q, %v, expected %q, error %v", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPgValues(t *testing.T) {
+	values := pgValues([]any{int64(1), "name", 90 * time.Second, nil})
+	interval, ok := values[2].(pgtype.Interval)
+	if !ok || !interval.Valid || interval.Microseconds != 90*1000000 {
+		t.Errorf("duration value %#v, expected interval of 90s", values[2])
+	}
+	if values[0] != int64(1) || values[1] != "name" || values[3] != nil {
+		t.Errorf("other values changed: %v", values)
+	}
+}
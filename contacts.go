@@ -0,0 +1,101 @@
+package uiscom
+
+import (
+	"context"
+)
+
+// ContactsResponse get.contacts result
+type ContactsResponse struct {
+	Data     []Contact `json:"data"`
+	Metadata Metadata  `json:"metadata"`
+}
+
+// Contact address book contact
+//
+//	{
+//	 "id": "number",
+//	 "first_name": "string",
+//	 "last_name": "string",
+//	 "patronymic": "string",
+//	 "full_name": "string",
+//	 "phone_numbers": [{"phone_number": "string", "phone_type": "string"}],
+//	 "emails": [{"email": "string", "email_type": "string"}],
+//	 "organization_id": "number",
+//	 "organization_name": "string",
+//	 "comment": "string",
+//	 "personal_manager_id": "number",
+//	 "custom_fields": [{"field_name": "string", "field_value": "string"}]
+//	}
+type Contact struct {
+	ID                int64                `json:"id,omitempty"`
+	FirstName         string               `json:"first_name,omitempty"`
+	LastName          string               `json:"last_name,omitempty"`
+	Patronymic        string               `json:"patronymic,omitempty"`
+	FullName          string               `json:"full_name,omitempty"`
+	PhoneNumbers      []ContactPhoneNumber `json:"phone_numbers,omitempty"`
+	Emails            []ContactEmail       `json:"emails,omitempty"`
+	OrganizationID    int64                `json:"organization_id,omitempty"`
+	OrganizationName  string               `json:"organization_name,omitempty"`
+	Comment           string               `json:"comment,omitempty"`
+	PersonalManagerID int64                `json:"personal_manager_id,omitempty"`
+	CustomFields      []ContactCustomField `json:"custom_fields,omitempty"`
+}
+
+// ContactPhoneNumber phone_type eg: "mobile", "work", "home", "other"
+type ContactPhoneNumber struct {
+	PhoneNumber string `json:"phone_number"`
+	PhoneType   string `json:"phone_type,omitempty"`
+}
+
+// ContactEmail email_type eg: "work", "personal", "other"
+type ContactEmail struct {
+	Email     string `json:"email"`
+	EmailType string `json:"email_type,omitempty"`
+}
+
+type ContactCustomField struct {
+	FieldName  string `json:"field_name"`
+	FieldValue string `json:"field_value"`
+}
+
+// ContactParams create.contacts/update.contacts parameters, zero values not sent,
+// optional values are pointers: nil not sent, pointer to zero value sent as 0, "" or [],
+// so string and list fields can be cleared on update
+type ContactParams struct {
+	ID                int64                 `json:"id,omitempty"`
+	FirstName         *string               `json:"first_name,omitempty"`
+	LastName          *string               `json:"last_name,omitempty"`
+	Patronymic        *string               `json:"patronymic,omitempty"`
+	FullName          *string               `json:"full_name,omitempty"`
+	PhoneNumbers      *[]ContactPhoneNumber `json:"phone_numbers,omitempty"`
+	Emails            *[]ContactEmail       `json:"emails,omitempty"`
+	OrganizationID    *int64                `json:"organization_id,omitempty"`
+	OrganizationName  *string               `json:"organization_name,omitempty"`
+	Comment           *string               `json:"comment,omitempty"`
+	PersonalManagerID *int64                `json:"personal_manager_id,omitempty"`
+	CustomFields      *[]ContactCustomField `json:"custom_fields,omitempty"`
+}
+
+func (c Client) GetContacts(ctx context.Context, userID int, limit, offset int, filter *Filter, fields ...Field) (*ContactsResponse, error) {
+	var resp ContactsResponse
+	err := c.callFor(ctx, &resp, "get.contacts", c.listParams(userID, limit, offset, filter, fields))
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateContact create contact, contact.ID ignored
+func (c Client) CreateContact(ctx context.Context, userID int, contact ContactParams) (int64, error) {
+	contact.ID = 0
+	return c.callForID(ctx, "create.contacts", userID, contact)
+}
+
+// UpdateContact update contact by contact.ID, nil fields not changed
+func (c Client) UpdateContact(ctx context.Context, userID int, contact ContactParams) (int64, error) {
+	return c.callForID(ctx, "update.contacts", userID, contact)
+}
+
+func (c Client) DeleteContact(ctx context.Context, userID int, id int64) (int64, error) {
+	return c.callForID(ctx, "delete.contacts", userID, map[string]any{"id": id})
+}
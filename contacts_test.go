@@ -0,0 +1,37 @@
+package uiscom
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestContactParamsJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		params ContactParams
+		want   string
+	}{
+		{name: "nil not sent", params: ContactParams{ID: 1}, want: `{"id":1}`},
+		{
+			name:   "zero values cleared",
+			params: ContactParams{ID: 1, Patronymic: Ptr(""), Emails: Ptr([]ContactEmail{}), PersonalManagerID: Ptr(int64(0))},
+			want:   `{"id":1,"patronymic":"","emails":[],"personal_manager_id":0}`,
+		},
+		{
+			name:   "values",
+			params: ContactParams{FirstName: Ptr("Ivan"), PhoneNumbers: Ptr([]ContactPhoneNumber{{PhoneNumber: "79260000000"}})},
+			want:   `{"first_name":"Ivan","phone_numbers":[{"phone_number":"79260000000"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("got %s, expected %s", b, tt.want)
+			}
+		})
+	}
+}
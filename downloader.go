@@ -0,0 +1,476 @@
+package uiscom
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DownloadTask record and calls report row for PathTemplate, Filename blank for name from server
+type DownloadTask struct {
+	Record   Record
+	Row      map[string]any
+	Filename string
+}
+
+type DownloadResult struct {
+	Task DownloadTask
+	// Key storage key
+	Key         string
+	Size        int64
+	SHA256      string
+	ContentType string
+	// Duration audio duration, 0 if unknown
+	Duration time.Duration
+	// Variants transcoded files
+	Variants []DownloadVariant
+	// Skipped record already downloaded and verified
+	Skipped bool
+	// Retained record moved into cold storage or deleted by Retention, not downloaded again
+	Retained bool
+	Err      error
+}
+
+// DownloadVariant transcoded record file
+type DownloadVariant struct {
+	Key string
+	// Variant channel name or "transcoded"
+	Variant string
+	Format  AudioFormat
+	Size    int64
+	SHA256  string
+}
+
+// Downloader concurrent records downloader. Records written into temp ".part" file in SpoolDir,
+// resumed by Range request after failure, verified and put into Storage.
+type Downloader struct {
+	Client  *Client
+	Storage Storage
+	// PathTemplate storage key template, nil for DefaultPathTemplate
+	PathTemplate *PathTemplate
+	// SpoolDir temp files folder, default system temp folder
+	SpoolDir string
+	// Workers parallel downloads, default 4
+	Workers int
+	// Manifest optional, downloaded records registry used for skip verified records:
+	// stored size must be equal, for LocalStorage SHA256 also verified, other storages checked by size only.
+	// Without Manifest any existing not empty record skipped.
+	Manifest *Manifest
+	// Sidecar put JSON metadata file with SidecarSuffix next to record
+	Sidecar bool
+	// Transcoder optional, transcoded files stored next to original record
+	// with ".<channel or transcoded>.<format>" suffix instead of extension
+	Transcoder Transcoder
+}
+
+func NewDownloader(client *Client, storage Storage, workers int, manifest *Manifest) *Downloader {
+	return &Downloader{
+		Client:   client,
+		Storage:  storage,
+		Workers:  workers,
+		Manifest: manifest,
+	}
+}
+
+// Download process all tasks, results in tasks order
+func (d *Downloader) Download(ctx context.Context, tasks ...DownloadTask) []DownloadResult {
+	workers := d.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+	results := make([]DownloadResult, len(tasks))
+	queue := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = d.download(ctx, tasks[i])
+			}
+		}()
+	}
+	for i := range tasks {
+		if ctx.Err() != nil {
+			results[i] = DownloadResult{Task: tasks[i], Err: ctx.Err()}
+			continue
+		}
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	return results
+}
+
+func (d *Downloader) download(ctx context.Context, task DownloadTask) DownloadResult {
+	result := DownloadResult{Task: task}
+
+	filename := task.Filename
+	if filename == "" {
+		info, err := d.Client.RecordInfo(ctx, task.Record.URL)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		filename = info.Filename
+	}
+	result.Key, result.Err = d.pathTemplate().Execute(task.Row, task.Record, filename)
+	if result.Err != nil {
+		return result
+	}
+
+	if d.Manifest != nil {
+		if entry, ok := d.Manifest.Get(result.Key); ok {
+			if entry.Cold || entry.Deleted {
+				result.Retained = true
+				return result
+			}
+			if d.stored(ctx, entry) {
+				result.Size = entry.Size
+				result.SHA256 = entry.SHA256
+				result.ContentType = entry.ContentType
+				result.Duration = entry.Duration
+				result.Skipped = true
+				return result
+			}
+		}
+	} else if size, err := d.Storage.Stat(ctx, result.Key); err == nil && size > 0 {
+		// without manifest existing record not verified, only size known
+		result.Size = size
+		result.Skipped = true
+		return result
+	}
+
+	spoolDir := d.SpoolDir
+	if spoolDir == "" {
+		spoolDir = filepath.Join(os.TempDir(), "uiscom-spool")
+	}
+	if err := os.MkdirAll(spoolDir, 0755); err != nil {
+		result.Err = fmt.Errorf("error creating spool directory: %w", err)
+		return result
+	}
+	urlHash := sha256.Sum256([]byte(task.Record.URL))
+	part := filepath.Join(spoolDir, hex.EncodeToString(urlHash[:16])+".part")
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	info, resumed, err := d.Client.DownloadRecordFrom(ctx, task.Record.URL, offset, f)
+	if offset > 0 && (err != nil || !resumed) && ctx.Err() == nil {
+		// server ignore or reject Range, download again from start
+		if err = f.Truncate(0); err == nil {
+			if _, err = f.Seek(0, io.SeekStart); err == nil {
+				info, _, err = d.Client.DownloadRecordFrom(ctx, task.Record.URL, 0, f)
+			}
+		}
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.ContentType = info.ContentType
+
+	if err := verifyContentType(info.ContentType); err != nil {
+		_ = os.Remove(part)
+		result.Err = fmt.Errorf("record %s: %w", task.Record.URL, err)
+		return result
+	}
+
+	result.Size, result.SHA256, err = fileChecksum(f)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if result.Size == 0 || (info.Size >= 0 && result.Size != info.Size) {
+		_ = os.Remove(part)
+		result.Err = fmt.Errorf("record %s: size %d, expected %d", task.Record.URL, result.Size, info.Size)
+		return result
+	}
+
+	result.Duration, _ = AudioDuration(f, result.Size)
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		result.Err = err
+		return result
+	}
+	if err := d.Storage.Put(ctx, result.Key, f, result.Size); err != nil {
+		result.Err = err
+		return result
+	}
+	f.Close()
+	defer os.Remove(part)
+
+	entry := ManifestEntry{
+		Path:            result.Key,
+		URL:             task.Record.URL,
+		CommunicationID: task.Record.CommunicationID,
+		Kind:            task.Record.Kind,
+		RecordID:        task.Record.ID,
+		Size:            result.Size,
+		SHA256:          result.SHA256,
+		ContentType:     result.ContentType,
+		Duration:        result.Duration,
+		DownloadedAt:    time.Now(),
+	}
+	if d.Sidecar {
+		b, err := json.MarshalIndent(Sidecar{ManifestEntry: entry, Call: task.Row}, "", "  ")
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		if err := d.Storage.Put(ctx, result.Key+SidecarSuffix, bytes.NewReader(b), int64(len(b))); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+	if d.Manifest != nil {
+		if err := d.Manifest.Add(entry); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	if d.Transcoder != nil {
+		result.Variants, result.Err = d.transcode(ctx, part, entry)
+	}
+	return result
+}
+
+// stored record of manifest entry exist and not corrupted
+func (d *Downloader) stored(ctx context.Context, entry ManifestEntry) bool {
+	size, err := d.Storage.Stat(ctx, entry.Path)
+	if err != nil || size != entry.Size {
+		return false
+	}
+	local, ok := d.Storage.(*LocalStorage)
+	if !ok || entry.SHA256 == "" {
+		return true
+	}
+	filename, err := local.Path(entry.Path)
+	if err != nil {
+		return false
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	_, sum, err := fileChecksum(f)
+	return err == nil && sum == entry.SHA256
+}
+
+func (d *Downloader) transcode(ctx context.Context, part string, original ManifestEntry) ([]DownloadVariant, error) {
+	files, err := d.Transcoder.Transcode(ctx, part, original.Kind)
+	for i := range files {
+		defer os.Remove(files[i].Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("transcode %s: %w", original.Path, err)
+	}
+
+	base := strings.TrimSuffix(original.Path, path.Ext(original.Path))
+	variants := make([]DownloadVariant, 0, len(files))
+	for i := range files {
+		variant := DownloadVariant{
+			Variant: files[i].Channel,
+			Format:  files[i].Format,
+		}
+		if variant.Variant == "" {
+			variant.Variant = "transcoded"
+		}
+		variant.Key = base + "." + variant.Variant + "." + variant.Format.String()
+
+		f, err := os.Open(files[i].Path)
+		if err != nil {
+			return variants, err
+		}
+		variant.Size, variant.SHA256, err = fileChecksum(f)
+		if err == nil {
+			_, err = f.Seek(0, io.SeekStart)
+		}
+		if err == nil {
+			err = d.Storage.Put(ctx, variant.Key, f, variant.Size)
+		}
+		f.Close()
+		if err != nil {
+			return variants, err
+		}
+
+		if d.Manifest != nil {
+			entry := original
+			entry.Path = variant.Key
+			entry.Variant = variant.Variant
+			entry.Size = variant.Size
+			entry.SHA256 = variant.SHA256
+			entry.ContentType = variant.Format.ContentType()
+			entry.DownloadedAt = time.Now()
+			if err := d.Manifest.Add(entry); err != nil {
+				return variants, err
+			}
+		}
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+func (d *Downloader) pathTemplate() *PathTemplate {
+	if d.PathTemplate != nil {
+		return d.PathTemplate
+	}
+	return defaultPathTemplate
+}
+
+// verifyContentType reject error pages received instead of audio
+func verifyContentType(contentType string) error {
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	if strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" {
+		return fmt.Errorf("unexpected content type %s", mediaType)
+	}
+	return nil
+}
+
+func fileChecksum(f *os.File) (int64, string, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, "", err
+	}
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ManifestEntry downloaded file
+type ManifestEntry struct {
+	// Path storage key
+	Path            string     `json:"path"`
+	URL             string     `json:"url"`
+	CommunicationID int64      `json:"communication_id"`
+	Kind            RecordKind `json:"kind"`
+	RecordID        string     `json:"record_id,omitempty"`
+	// Variant blank for original record, channel name or "transcoded" for transcoded file
+	Variant     string `json:"variant,omitempty"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	ContentType string `json:"content_type,omitempty"`
+	// Duration nanoseconds, 0 if unknown
+	Duration     time.Duration `json:"duration"`
+	DownloadedAt time.Time     `json:"downloaded_at"`
+	// Cold record moved into cold storage by retention
+	Cold bool `json:"cold,omitempty"`
+	// Deleted record deleted by retention
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// SidecarSuffix suffix of record metadata file key
+const SidecarSuffix = ".json"
+
+// Sidecar record metadata file content
+type Sidecar struct {
+	ManifestEntry
+	Call map[string]any `json:"call,omitempty"`
+}
+
+// Manifest append only JSON lines registry of downloaded files, last entry for path wins
+type Manifest struct {
+	mu      sync.Mutex
+	file    *os.File
+	entries map[string]ManifestEntry
+}
+
+// OpenManifest open or create manifest file
+func OpenManifest(filename string) (*Manifest, error) {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{
+		file:    f,
+		entries: map[string]ManifestEntry{},
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry ManifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// truncated last line after crash
+			continue
+		}
+		m.entries[entry.Path] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Manifest) Get(path string) (ManifestEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[path]
+	return entry, ok
+}
+
+func (m *Manifest) Add(entry ManifestEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	m.entries[entry.Path] = entry
+	return nil
+}
+
+// Entries copy of all entries
+func (m *Manifest) Entries() []ManifestEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := make([]ManifestEntry, 0, len(m.entries))
+	for _, entry := range m.entries {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (m *Manifest) Close() error {
+	if m == nil || m.file == nil {
+		return errors.New("manifest not opened")
+	}
+	return m.file.Close()
+}
@@ -0,0 +1,153 @@
+package uiscom
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func putObject(t *testing.T, s Storage, key, content string) {
+	t.Helper()
+	if err := s.Put(context.Background(), key, strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownloaderStored(t *testing.T) {
+	const content = "record content"
+	tests := []struct {
+		name   string
+		stored string
+		entry  ManifestEntry
+		want   bool
+	}{
+		{
+			name:   "verified",
+			stored: content,
+			entry:  ManifestEntry{Size: int64(len(content)), SHA256: sha256Hex(content)},
+			want:   true,
+		},
+		{
+			name:  "missing",
+			entry: ManifestEntry{Size: int64(len(content)), SHA256: sha256Hex(content)},
+		},
+		{
+			name:   "other size",
+			stored: content[:5],
+			entry:  ManifestEntry{Size: int64(len(content)), SHA256: sha256Hex(content)},
+		},
+		{
+			name:   "corrupted same size",
+			stored: strings.Repeat("x", len(content)),
+			entry:  ManifestEntry{Size: int64(len(content)), SHA256: sha256Hex(content)},
+		},
+		{
+			name:   "without checksum",
+			stored: content,
+			entry:  ManifestEntry{Size: int64(len(content))},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewLocalStorage(t.TempDir())
+			tt.entry.Path = "2024/03/01/42/record.mp3"
+			if tt.stored != "" {
+				putObject(t, storage, tt.entry.Path, tt.stored)
+			}
+			d := &Downloader{Storage: storage}
+			if got := d.stored(context.Background(), tt.entry); got != tt.want {
+				t.Errorf("stored %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloaderSkipWithoutManifest(t *testing.T) {
+	storage := NewLocalStorage(t.TempDir())
+	task := DownloadTask{
+		Record:   Record{CommunicationID: 42, Kind: RecordKindTalk, ID: "abc", URL: "http://127.0.0.1:1/record"},
+		Row:      map[string]any{"start_time": "2024-03-01 10:00:00"},
+		Filename: "record.mp3",
+	}
+	key, err := defaultPathTemplate.Execute(task.Row, task.Record, task.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	putObject(t, storage, key, "record content")
+
+	d := &Downloader{Storage: storage, SpoolDir: t.TempDir()}
+	r := d.Download(context.Background(), task)[0]
+	if r.Err != nil || !r.Skipped || r.Size != int64(len("record content")) {
+		t.Errorf("result %+v, expected skipped existing record", r)
+	}
+}
+
+func TestDownloaderRetainedNotSkipped(t *testing.T) {
+	manifest, err := OpenManifest(filepath.Join(t.TempDir(), "manifest.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer manifest.Close()
+	task := DownloadTask{
+		Record:   Record{CommunicationID: 42, Kind: RecordKindTalk, ID: "abc", URL: "http://127.0.0.1:1/record"},
+		Row:      map[string]any{"start_time": "2024-03-01 10:00:00"},
+		Filename: "record.mp3",
+	}
+	key, _ := defaultPathTemplate.Execute(task.Row, task.Record, task.Filename)
+	if err := manifest.Add(ManifestEntry{Path: key, Size: 10, Deleted: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Downloader{Storage: NewLocalStorage(t.TempDir()), Manifest: manifest}
+	r := d.Download(context.Background(), task)[0]
+	if r.Err != nil || r.Skipped || !r.Retained {
+		t.Errorf("result %+v, expected retained record", r)
+	}
+}
+
+func TestManifestReload(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "manifest.jsonl")
+	m, err := OpenManifest(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []ManifestEntry{
+		{Path: "a.mp3", Size: 1, SHA256: "1"},
+		{Path: "b.mp3", Size: 2, SHA256: "2"},
+		// later entry of the same path replace previous
+		{Path: "a.mp3", Size: 3, SHA256: "3", Cold: true},
+	}
+	for _, e := range entries {
+		if err := m.Add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = OpenManifest(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if got := len(m.Entries()); got != 2 {
+		t.Errorf("entries %d, expected 2", got)
+	}
+	a, ok := m.Get("a.mp3")
+	if !ok || a.Size != 3 || !a.Cold {
+		t.Errorf("a.mp3 %+v, expected last entry", a)
+	}
+	if _, ok := m.Get("c.mp3"); ok {
+		t.Error("c.mp3 found")
+	}
+}
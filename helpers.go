@@ -3,6 +3,7 @@ package uiscom
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -16,6 +17,32 @@ func StringToTime(s string) (time.Time, error) {
 	return time.Parse(DateFormat, s)
 }
 
+// DateTime time in the API DateFormat, null and empty string decode to zero time
+type DateTime struct {
+	time.Time
+}
+
+func (t DateTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.Quote(TimeToString(t.Time))), nil
+}
+
+func (t *DateTime) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" || s == `""` {
+		t.Time = time.Time{}
+		return nil
+	}
+	s, err := strconv.Unquote(s)
+	if err != nil {
+		return err
+	}
+	t.Time, err = StringToTime(s)
+	return err
+}
+
 func PrettyPrint(v interface{}) (err error) {
 	b, err := json.MarshalIndent(v, "", "  ")
 	if err == nil {
@@ -23,3 +50,9 @@ func PrettyPrint(v interface{}) (err error) {
 	}
 	return
 }
+
+// Ptr pointer to v for optional parameters, nil pointer not sent and
+// pointer to zero value sent as zero value, not as null
+func Ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,230 @@
+package uiscom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	ComagicMediaURL          = `https://app.comagic.ru/system/media/`
+	ComagicTalkMediaURL      = ComagicMediaURL + `talk/`
+	ComagicVoiceMailMediaURL = ComagicMediaURL + `voice_mail/`
+)
+
+// MediaURL media host of target
+func (t Target) MediaURL() string {
+	switch t {
+	case TargetComagic, CallTargetComagic:
+		return ComagicMediaURL
+	default:
+		return UiscomMediaURL
+	}
+}
+
+type RecordKind string
+
+func (k RecordKind) String() string {
+	return string(k)
+}
+
+const (
+	RecordKindTalk      = RecordKind("talk")
+	RecordKindVoiceMail = RecordKind("voice_mail")
+	// RecordKindFull merged talk record from full_record_file_link
+	RecordKindFull = RecordKind("full")
+)
+
+// Record media record of communication
+type Record struct {
+	CommunicationID int64
+	Kind            RecordKind
+	// ID record hash from call_records or voice_mail_records, blank for RecordKindFull
+	ID  string
+	URL string
+}
+
+// RecordInfo media file properties, Size -1 if unknown
+type RecordInfo struct {
+	ContentType string
+	Size        int64
+	Filename    string
+}
+
+// RecordURL media URL of talk or voice mail record
+func (c Client) RecordURL(kind RecordKind, communicationID int64, recordID string) (string, error) {
+	switch kind {
+	case RecordKindTalk, RecordKindVoiceMail:
+	default:
+		return "", fmt.Errorf("record kind %q not have media URL", kind)
+	}
+	return url.JoinPath(c.mediaURL(), kind.String(), strconv.FormatInt(communicationID, 10), recordID, "/")
+}
+
+// CallRecords records of calls report row, row must have "communication_id" and
+// may have "call_records", "voice_mail_records" and "full_record_file_link" fields
+func (c Client) CallRecords(row map[string]any) ([]Record, error) {
+	communicationID, err := rowInt64(row["communication_id"])
+	if err != nil {
+		return nil, fmt.Errorf("communication_id: %w", err)
+	}
+
+	var records []Record
+	for _, kind := range []RecordKind{RecordKindTalk, RecordKindVoiceMail} {
+		field := "call_records"
+		if kind == RecordKindVoiceMail {
+			field = "voice_mail_records"
+		}
+		ids, _ := row[field].([]any)
+		for i := range ids {
+			id, ok := ids[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: wrong record type %T", field, ids[i])
+			}
+			u, err := c.RecordURL(kind, communicationID, id)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, Record{CommunicationID: communicationID, Kind: kind, ID: id, URL: u})
+		}
+	}
+	if link, ok := row["full_record_file_link"].(string); ok && link != "" {
+		records = append(records, Record{CommunicationID: communicationID, Kind: RecordKindFull, URL: link})
+	}
+	return records, nil
+}
+
+// RecordInfo get record properties by HEAD request
+func (c Client) RecordInfo(ctx context.Context, recordURL string) (RecordInfo, error) {
+	resp, err := c.mediaRequest(ctx, http.MethodHead, recordURL, nil)
+	if err != nil {
+		return RecordInfo{}, err
+	}
+	resp.Body.Close()
+	return recordInfo(resp)
+}
+
+// DownloadRecord stream record to w
+func (c Client) DownloadRecord(ctx context.Context, recordURL string, w io.Writer) (RecordInfo, error) {
+	info, _, err := c.DownloadRecordFrom(ctx, recordURL, 0, w)
+	return info, err
+}
+
+// DownloadRecordFrom stream record to w starting from offset byte by Range request.
+// If server ignore Range whole record is written and resumed is false.
+// Returned info Size is full record size.
+func (c Client) DownloadRecordFrom(ctx context.Context, recordURL string, offset int64, w io.Writer) (info RecordInfo, resumed bool, err error) {
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	resp, err := c.mediaRequest(ctx, http.MethodGet, recordURL, header)
+	if err != nil {
+		return RecordInfo{}, false, err
+	}
+	defer resp.Body.Close()
+	info, err = recordInfo(resp)
+	if err != nil {
+		return info, false, err
+	}
+	expected := info.Size
+	if resp.StatusCode == http.StatusPartialContent {
+		resumed = true
+		info.Size = -1
+		if cr := resp.Header.Get("Content-Range"); cr != "" {
+			if i := strings.LastIndex(cr, "/"); i >= 0 {
+				if total, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+					info.Size = total
+				}
+			}
+		}
+	}
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return info, resumed, err
+	}
+	if expected >= 0 && n != expected {
+		return info, resumed, fmt.Errorf("record %s: received %d of %d bytes", recordURL, n, expected)
+	}
+	if !resumed {
+		info.Size = n
+	}
+	return info, resumed, nil
+}
+
+func (c Client) mediaURL() string {
+	if c.MediaURL != "" {
+		return c.MediaURL
+	}
+	return UiscomMediaURL
+}
+
+func (c Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c Client) mediaRequest(ctx context.Context, method, recordURL string, header http.Header) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, recordURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k := range header {
+		request.Header[k] = header[k]
+	}
+	resp, err := c.httpClient().Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, fmt.Errorf("record %s: %s", recordURL, resp.Status)
+	}
+	return resp, nil
+}
+
+func recordInfo(resp *http.Response) (RecordInfo, error) {
+	info := RecordInfo{
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        resp.ContentLength,
+	}
+	filename := resp.Request.URL.Path
+	if cd := resp.Header.Get("Content-Disposition"); cd != "" {
+		if _, params, err := mime.ParseMediaType(cd); err == nil {
+			if val, ok := params["filename"]; ok {
+				filename = val
+			}
+		}
+	}
+	filename = path.Base(path.Clean("/" + strings.ReplaceAll(filename, `\`, "/")))
+	if filename == "" || filename == "." || filename == "/" {
+		return info, errors.New("filename couldn't be determined")
+	}
+	info.Filename = filename
+	return info, nil
+}
+
+func rowInt64(v any) (int64, error) {
+	switch v := v.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case json.Number:
+		return v.Int64()
+	case float64:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("wrong type %T", v)
+	}
+}
@@ -0,0 +1,242 @@
+package uiscom
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+func (t EventType) String() string {
+	return string(t)
+}
+
+const (
+	EventCallStarted  = EventType("call_started")
+	EventCallAnswered = EventType("call_answered")
+	EventCallFinished = EventType("call_finished")
+	EventCallLost     = EventType("call_lost")
+	EventChatStarted  = EventType("chat_started")
+	EventChatMessage  = EventType("chat_message")
+	EventChatFinished = EventType("chat_finished")
+)
+
+// NotificationSecretHeader header with shared secret, "secret" query parameter also accepted
+const NotificationSecretHeader = "X-Uis-Secret"
+
+// Event HTTP notification, notification template in UIS must send parameters with the same names
+//
+//	{
+//	 "event": "call_finished",
+//	 "notification_time": "2006-01-02 15:04:05",
+//	 "call_session_id": "number",
+//	 "communication_id": "number",
+//	 "chat_id": "number",
+//	 "direction": "in|out",
+//	 "virtual_phone_number": "string",
+//	 "contact_phone_number": "string",
+//	 "employee_id": "number",
+//	 "employee_full_name": "string",
+//	 "scenario_id": "number",
+//	 "start_time": "2006-01-02 15:04:05",
+//	 "finish_time": "2006-01-02 15:04:05",
+//	 "talk_duration": "number",
+//	 "is_lost": "boolean",
+//	 "message": "string"
+//	}
+type Event struct {
+	Type               EventType
+	NotificationTime   time.Time
+	CallSessionID      int64
+	CommunicationID    int64
+	ChatID             int64
+	Direction          string
+	VirtualPhoneNumber string
+	ContactPhoneNumber string
+	EmployeeID         int64
+	EmployeeFullName   string
+	ScenarioID         int64
+	StartTime          time.Time
+	FinishTime         time.Time
+	TalkDuration       time.Duration
+	IsLost             bool
+	Message            string
+
+	// Raw all received parameters as strings
+	Raw map[string]string
+}
+
+// NotificationHandler http.Handler receive UIS HTTP notifications and dispatch them
+// to registered callbacks and channel
+type NotificationHandler struct {
+	// Secret if not blank request must have it in NotificationSecretHeader header or "secret" query parameter
+	Secret string
+
+	mu        sync.RWMutex
+	callbacks map[EventType][]func(Event)
+	all       []func(Event)
+	events    chan Event
+}
+
+func NewNotificationHandler(secret string) *NotificationHandler {
+	return &NotificationHandler{
+		Secret:    secret,
+		callbacks: map[EventType][]func(Event){},
+	}
+}
+
+// On register callback for event type, callbacks called synchronously in request goroutine
+func (h *NotificationHandler) On(t EventType, f func(Event)) {
+	h.mu.Lock()
+	h.callbacks[t] = append(h.callbacks[t], f)
+	h.mu.Unlock()
+}
+
+// OnAll register callback for all event types
+func (h *NotificationHandler) OnAll(f func(Event)) {
+	h.mu.Lock()
+	h.all = append(h.all, f)
+	h.mu.Unlock()
+}
+
+// Events return channel with all events, created on first call with size buffer.
+// Request wait for free place in channel until request context done.
+func (h *NotificationHandler) Events(size int) <-chan Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.events == nil {
+		h.events = make(chan Event, size)
+	}
+	return h.events
+}
+
+func (h *NotificationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Secret != "" {
+		secret := r.Header.Get(NotificationSecretHeader)
+		if secret == "" {
+			secret = r.URL.Query().Get("secret")
+		}
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(h.Secret)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	event, err := ParseNotification(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.mu.RLock()
+	callbacks := append(append([]func(Event){}, h.callbacks[event.Type]...), h.all...)
+	events := h.events
+	h.mu.RUnlock()
+
+	// channel first, so retried after 503 request not run callbacks twice
+	if events != nil {
+		select {
+		case events <- event:
+		case <-r.Context().Done():
+			http.Error(w, "event not delivered", http.StatusServiceUnavailable)
+			return
+		}
+	}
+	for i := range callbacks {
+		callbacks[i](event)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// ParseNotification parse notification from JSON body, form body or query parameters
+func ParseNotification(r *http.Request) (Event, error) {
+	raw := map[string]string{}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		values := map[string]any{}
+		d := json.NewDecoder(io.LimitReader(r.Body, 1<<20))
+		d.UseNumber()
+		if err := d.Decode(&values); err != nil {
+			return Event{}, fmt.Errorf("notification body: %w", err)
+		}
+		for k, v := range values {
+			switch v := v.(type) {
+			case nil:
+			case string:
+				raw[k] = v
+			case json.Number:
+				raw[k] = v.String()
+			case bool:
+				raw[k] = strconv.FormatBool(v)
+			default:
+				b, _ := json.Marshal(v)
+				raw[k] = string(b)
+			}
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return Event{}, err
+		}
+		for k := range r.Form {
+			raw[k] = r.Form.Get(k)
+		}
+	}
+	delete(raw, "secret")
+	return eventFromRaw(raw)
+}
+
+func eventFromRaw(raw map[string]string) (Event, error) {
+	e := Event{Raw: raw}
+	e.Type = EventType(raw["event"])
+	if e.Type == "" {
+		return e, fmt.Errorf("notification without event")
+	}
+
+	var err error
+	parseInt := func(name string) int64 {
+		if raw[name] == "" || err != nil {
+			return 0
+		}
+		var v int64
+		v, err = strconv.ParseInt(raw[name], 10, 64)
+		if err != nil {
+			err = fmt.Errorf("notification %s: %w", name, err)
+		}
+		return v
+	}
+	parseTime := func(name string) time.Time {
+		if raw[name] == "" || err != nil {
+			return time.Time{}
+		}
+		var v time.Time
+		v, err = StringToTime(raw[name])
+		if err != nil {
+			err = fmt.Errorf("notification %s: %w", name, err)
+		}
+		return v
+	}
+
+	e.NotificationTime = parseTime("notification_time")
+	e.CallSessionID = parseInt("call_session_id")
+	e.CommunicationID = parseInt("communication_id")
+	e.ChatID = parseInt("chat_id")
+	e.Direction = raw["direction"]
+	e.VirtualPhoneNumber = raw["virtual_phone_number"]
+	e.ContactPhoneNumber = raw["contact_phone_number"]
+	e.EmployeeID = parseInt("employee_id")
+	e.EmployeeFullName = raw["employee_full_name"]
+	e.ScenarioID = parseInt("scenario_id")
+	e.StartTime = parseTime("start_time")
+	e.FinishTime = parseTime("finish_time")
+	e.TalkDuration = time.Duration(parseInt("talk_duration")) * time.Second
+	e.IsLost = raw["is_lost"] == "true" || raw["is_lost"] == "1"
+	e.Message = raw["message"]
+	return e, err
+}
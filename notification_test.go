@@ -0,0 +1,156 @@
+package uiscom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNotification(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		contentType string
+		target      string
+		body        string
+		want        Event
+		wantErr     bool
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			target:      "/",
+			body:        `{"event":"call_finished","communication_id":42,"call_session_id":"7","start_time":"2024-03-01 10:00:00","talk_duration":30,"is_lost":false,"employee_full_name":null}`,
+			want:        Event{Type: EventCallFinished, CommunicationID: 42, CallSessionID: 7, StartTime: start, TalkDuration: 30 * time.Second},
+		},
+		{
+			name:        "json lost",
+			contentType: "application/json",
+			target:      "/",
+			body:        `{"event":"call_lost","communication_id":42,"is_lost":true}`,
+			want:        Event{Type: EventCallLost, CommunicationID: 42, IsLost: true},
+		},
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			target:      "/",
+			body:        "event=call_answered&communication_id=42&direction=in&start_time=2024-03-01+10%3A00%3A00",
+			want:        Event{Type: EventCallAnswered, CommunicationID: 42, Direction: "in", StartTime: start},
+		},
+		{
+			name:   "query",
+			target: "/?event=call_started&communication_id=42&is_lost=1&secret=s",
+			want:   Event{Type: EventCallStarted, CommunicationID: 42, IsLost: true},
+		},
+		{
+			name:        "json broken",
+			contentType: "application/json",
+			target:      "/",
+			body:        `{"event":`,
+			wantErr:     true,
+		},
+		{
+			name:    "without event",
+			target:  "/?communication_id=42",
+			wantErr: true,
+		},
+		{
+			name:    "wrong number",
+			target:  "/?event=call_started&communication_id=abc",
+			wantErr: true,
+		},
+		{
+			name:    "wrong time",
+			target:  "/?event=call_started&start_time=yesterday",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			got, err := ParseNotification(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, expected error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if _, ok := got.Raw["secret"]; ok {
+				t.Error("secret kept in raw parameters")
+			}
+			got.Raw, tt.want.Raw = nil, nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, expected %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotificationHandlerSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		target string
+		status int
+	}{
+		{name: "header", secret: "s", header: "s", target: "/?event=call_started", status: http.StatusOK},
+		{name: "query", secret: "s", target: "/?event=call_started&secret=s", status: http.StatusOK},
+		{name: "missing", secret: "s", target: "/?event=call_started", status: http.StatusForbidden},
+		{name: "wrong", secret: "s", header: "x", target: "/?event=call_started", status: http.StatusForbidden},
+		{name: "not required", target: "/?event=call_started", status: http.StatusOK},
+		{name: "bad request", target: "/?communication_id=42", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewNotificationHandler(tt.secret)
+			var called int
+			h.OnAll(func(Event) { called++ })
+
+			r := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.header != "" {
+				r.Header.Set(NotificationSecretHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("status %d, expected %d", w.Code, tt.status)
+			}
+			want := 0
+			if tt.status == http.StatusOK {
+				want = 1
+			}
+			if called != want {
+				t.Errorf("callback called %d times, expected %d", called, want)
+			}
+		})
+	}
+}
+
+func TestNotificationHandlerDispatch(t *testing.T) {
+	h := NewNotificationHandler("")
+	events := h.Events(1)
+	var started, all int
+	h.On(EventCallStarted, func(Event) { started++ })
+	h.OnAll(func(Event) { all++ })
+
+	for _, target := range []string{"/?event=call_finished&communication_id=1", "/?event=call_started&communication_id=2"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d, expected %d", w.Code, http.StatusOK)
+		}
+		if e := <-events; e.CommunicationID == 0 {
+			t.Errorf("event %+v without communication id", e)
+		}
+	}
+	if started != 1 || all != 2 {
+		t.Errorf("started callbacks %d, all callbacks %d, expected 1 and 2", started, all)
+	}
+}
@@ -67,19 +67,68 @@ func (e *Error) Error() string {
 //	   "total_items":"number"
 //	 }
 //	}
+//
+// Fields typed as in API response, before they were strings, see CHANGELOG.md
 type Metadata struct {
 	APIVersion struct {
-		CurrentVersionDeprecated string `json:"current_version_deprecated"`
+		CurrentVersionDeprecated bool   `json:"current_version_deprecated"`
 		CurrentVersion           string `json:"current_version"`
 		LatestVersion            string `json:"latest_version"`
 	} `json:"api_version"`
 	Limits struct {
-		DayLimit        string `json:"day_limit"`
-		DayRemaining    string `json:"day_remaining"`
-		DayReset        string `json:"day_reset"`
-		MinuteLimit     string `json:"minute_limit"`
-		MinuteRemaining string `json:"minute_remaining"`
-		MinuteReset     string `json:"minute_reset"`
+		DayLimit        int `json:"day_limit"`
+		DayRemaining    int `json:"day_remaining"`
+		DayReset        int `json:"day_reset"`
+		MinuteLimit     int `json:"minute_limit"`
+		MinuteRemaining int `json:"minute_remaining"`
+		MinuteReset     int `json:"minute_reset"`
 	} `json:"limits"`
-	TotalItems string `json:"total_items"`
+	TotalItems int `json:"total_items"`
+}
+
+// IDResponse result of create.*, update.* and delete.* methods
+//
+//	{
+//	 "data": {"id": "number"},
+//	 "metadata": Metadata
+//	}
+type IDResponse struct {
+	Data struct {
+		ID int64 `json:"id"`
+	} `json:"data"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// FinancialCallLegsReport get.financial_call_legs_report result
+//
+//	{
+//	 "data": [FinancialCallLeg],
+//	 "metadata": Metadata
+//	}
+type FinancialCallLegsReport struct {
+	Data     []FinancialCallLeg `json:"data"`
+	Metadata Metadata           `json:"metadata"`
+}
+
+// FinancialCallLeg one charged call leg
+type FinancialCallLeg struct {
+	ID                  int64    `json:"id"`
+	CallSessionID       int64    `json:"call_session_id"`
+	StartTime           DateTime `json:"start_time"`
+	Direction           string   `json:"direction"`
+	VirtualPhoneNumber  string   `json:"virtual_phone_number"`
+	CallingPhoneNumber  string   `json:"calling_phone_number"`
+	CalledPhoneNumber   string   `json:"called_phone_number"`
+	EmployeeID          *int64   `json:"employee_id"`
+	EmployeeFullName    *string  `json:"employee_full_name"`
+	Duration            int      `json:"duration"`
+	TotalDuration       int      `json:"total_duration"`
+	BillingDuration     int      `json:"billing_duration"`
+	Tariff              string   `json:"tariff"`
+	TariffPrice         float64  `json:"tariff_price"`
+	Cost                float64  `json:"cost"`
+	Currency            string   `json:"currency"`
+	IsOperator          bool     `json:"is_operator"`
+	FinishReason        string   `json:"finish_reason"`
+	DestinationZoneName *string  `json:"destination_zone_name"`
 }
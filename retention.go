@@ -0,0 +1,158 @@
+package uiscom
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type RetentionAction string
+
+func (a RetentionAction) String() string {
+	return string(a)
+}
+
+const (
+	RetentionKeep   = RetentionAction("keep")
+	RetentionDelete = RetentionAction("delete")
+	RetentionMove   = RetentionAction("move")
+)
+
+// RetentionRecord stored record, Time is call start time or download time
+type RetentionRecord struct {
+	Key  string
+	Time time.Time
+	Tags []string
+}
+
+type RetentionResult struct {
+	Record RetentionRecord
+	Action RetentionAction
+	// ColdKey key in Cold storage for moved record
+	ColdKey string
+	Err     error
+}
+
+// Retention records retention policy: records older than MaxAge deleted or moved into Cold storage,
+// records of calls with tags from TagMaxAge kept by the longest tag age
+type Retention struct {
+	Storage Storage
+	// Cold optional, if set old records moved into it instead of delete
+	Cold Storage
+	// Manifest optional, updated for deleted and moved records
+	Manifest *Manifest
+
+	MaxAge    time.Duration
+	TagMaxAge map[string]time.Duration
+	// DryRun only decide actions
+	DryRun bool
+}
+
+// Decide action for record at now
+func (r *Retention) Decide(record RetentionRecord, now time.Time) RetentionAction {
+	if r.MaxAge <= 0 {
+		return RetentionKeep
+	}
+	maxAge := r.MaxAge
+	for i := range record.Tags {
+		if age, ok := r.TagMaxAge[record.Tags[i]]; ok && age > maxAge {
+			maxAge = age
+		}
+	}
+	if now.Sub(record.Time) <= maxAge {
+		return RetentionKeep
+	}
+	if r.Cold != nil {
+		return RetentionMove
+	}
+	return RetentionDelete
+}
+
+// Apply policy to records, results in records order
+func (r *Retention) Apply(ctx context.Context, records []RetentionRecord, now time.Time) []RetentionResult {
+	results := make([]RetentionResult, len(records))
+	for i := range records {
+		results[i] = RetentionResult{Record: records[i], Action: r.Decide(records[i], now)}
+		if ctx.Err() != nil {
+			results[i].Err = ctx.Err()
+			continue
+		}
+		switch results[i].Action {
+		case RetentionMove:
+			results[i].ColdKey = records[i].Key
+			if !r.DryRun {
+				results[i].Err = r.move(ctx, records[i].Key)
+			}
+		case RetentionDelete:
+			if !r.DryRun {
+				results[i].Err = r.delete(ctx, records[i].Key)
+			}
+		}
+	}
+	return results
+}
+
+// move record and its sidecar into Cold storage
+func (r *Retention) move(ctx context.Context, key string) error {
+	if err := r.moveObject(ctx, key); err != nil {
+		return err
+	}
+	err := r.moveObject(ctx, key+SidecarSuffix)
+	if err != nil && err != ErrNotExist {
+		return err
+	}
+	return r.updateManifest(key, func(entry *ManifestEntry) {
+		entry.Cold = true
+	})
+}
+
+func (r *Retention) moveObject(ctx context.Context, key string) error {
+	size, err := r.Storage.Stat(ctx, key)
+	if err != nil {
+		return err
+	}
+	rc, err := r.Storage.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	err = r.Cold.Put(ctx, key, rc, size)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("move %s: %w", key, err)
+	}
+	coldSize, err := r.Cold.Stat(ctx, key)
+	if err != nil {
+		return err
+	}
+	if coldSize != size {
+		return fmt.Errorf("move %s: cold size %d, expected %d", key, coldSize, size)
+	}
+	return r.Storage.Delete(ctx, key)
+}
+
+// delete record and its sidecar
+func (r *Retention) delete(ctx context.Context, key string) error {
+	err := r.Storage.Delete(ctx, key)
+	if err != nil && err != ErrNotExist {
+		return err
+	}
+	err = r.Storage.Delete(ctx, key+SidecarSuffix)
+	if err != nil && err != ErrNotExist {
+		return err
+	}
+	return r.updateManifest(key, func(entry *ManifestEntry) {
+		entry.Deleted = true
+	})
+}
+
+func (r *Retention) updateManifest(key string, update func(entry *ManifestEntry)) error {
+	if r.Manifest == nil {
+		return nil
+	}
+	entry, ok := r.Manifest.Get(key)
+	if !ok {
+		return nil
+	}
+	update(&entry)
+	return r.Manifest.Add(entry)
+}
@@ -0,0 +1,440 @@
+package uiscom
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColumnType Go and SQL type of report field
+type ColumnType int
+
+const (
+	// ColumnInt64 number decoded as int64, bigint
+	ColumnInt64 ColumnType = iota
+	// ColumnFloat64 number decoded as float64, numeric
+	ColumnFloat64
+	// ColumnString string, character varying(Size) or text if size not set
+	ColumnString
+	// ColumnBool boolean
+	ColumnBool
+	// ColumnTime DateFormat string decoded as time.Time, timestamp without time zone
+	ColumnTime
+	// ColumnDuration seconds decoded as time.Duration, interval
+	ColumnDuration
+	// ColumnJSON nested object or array kept as decoded, jsonb
+	ColumnJSON
+)
+
+func (t ColumnType) String() string {
+	switch t {
+	case ColumnInt64:
+		return "int64"
+	case ColumnFloat64:
+		return "float64"
+	case ColumnString:
+		return "string"
+	case ColumnBool:
+		return "bool"
+	case ColumnTime:
+		return "time"
+	case ColumnDuration:
+		return "duration"
+	case ColumnJSON:
+		return "json"
+	default:
+		return "ColumnType(" + strconv.Itoa(int(t)) + ")"
+	}
+}
+
+// Column report field stored in table column with the same name
+type Column struct {
+	Name string
+	Type ColumnType
+	// Size max length of ColumnString
+	Size int
+	// Key part of primary key, key columns go first
+	Key bool
+	// NotNull null value is decode error
+	NotNull bool
+}
+
+// Field report field of column
+func (c Column) Field() Field {
+	return Field(c.Name)
+}
+
+// SQLType Postgres column type
+func (c Column) SQLType() string {
+	switch c.Type {
+	case ColumnInt64:
+		return "bigint"
+	case ColumnFloat64:
+		return "numeric"
+	case ColumnString:
+		if c.Size > 0 {
+			return "character varying(" + strconv.Itoa(c.Size) + ") COLLATE pg_catalog.\"default\""
+		}
+		return "text COLLATE pg_catalog.\"default\""
+	case ColumnBool:
+		return "boolean"
+	case ColumnTime:
+		return "timestamp without time zone"
+	case ColumnDuration:
+		return "interval"
+	default:
+		return "jsonb"
+	}
+}
+
+// Decode report field value to column Go type, nil for null
+func (c Column) Decode(v any) (any, error) {
+	if v == nil {
+		if c.NotNull || c.Key {
+			return nil, fmt.Errorf("%s: null value", c.Name)
+		}
+		return nil, nil
+	}
+	var (
+		val any
+		err error
+	)
+	switch c.Type {
+	case ColumnInt64:
+		val, err = rowInt64(v)
+	case ColumnFloat64:
+		switch n := v.(type) {
+		case json.Number:
+			val, err = n.Float64()
+		case float64:
+			val = n
+		default:
+			err = fmt.Errorf("wrong type %T", v)
+		}
+	case ColumnString:
+		switch s := v.(type) {
+		case string:
+			val = s
+		case json.Number:
+			// identifiers like ua_client_id may come as number
+			val = s.String()
+		default:
+			err = fmt.Errorf("wrong type %T", v)
+		}
+	case ColumnBool:
+		b, ok := v.(bool)
+		if !ok {
+			err = fmt.Errorf("wrong type %T", v)
+		}
+		val = b
+	case ColumnTime:
+		s, ok := v.(string)
+		switch {
+		case !ok:
+			err = fmt.Errorf("wrong type %T", v)
+		case s == "":
+			return nil, nil
+		default:
+			val, err = StringToTime(s)
+		}
+	case ColumnDuration:
+		var seconds float64
+		switch n := v.(type) {
+		case json.Number:
+			seconds, err = n.Float64()
+		case float64:
+			seconds = n
+		default:
+			err = fmt.Errorf("wrong type %T", v)
+		}
+		val = time.Duration(seconds * float64(time.Second))
+	default:
+		val = v
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.Name, err)
+	}
+	return val, nil
+}
+
+// Schema declarative report table, columns decoded from report fields with the same names
+type Schema struct {
+	Table   string
+	Columns []Column
+}
+
+// Fields report fields requested for schema
+func (s Schema) Fields() []Field {
+	fields := make([]Field, len(s.Columns))
+	for i := range s.Columns {
+		fields[i] = s.Columns[i].Field()
+	}
+	return fields
+}
+
+// ColumnNames table column names in schema order
+func (s Schema) ColumnNames() []string {
+	names := make([]string, len(s.Columns))
+	for i := range s.Columns {
+		names[i] = s.Columns[i].Name
+	}
+	return names
+}
+
+// Keys count of primary key columns
+func (s Schema) Keys() int {
+	var n int
+	for i := range s.Columns {
+		if s.Columns[i].Key {
+			n++
+		}
+	}
+	return n
+}
+
+// DecodeMap decode report row to column values by column names,
+// any absent or undecodable field is error
+func (s Schema) DecodeMap(row any) (map[string]any, error) {
+	val, warnings, err := s.decode(row, false)
+	if err != nil {
+		return val, err
+	}
+	if len(warnings) != 0 {
+		return val, warnings[0]
+	}
+	return val, nil
+}
+
+// DecodeMapLenient decode report row like DecodeMap, but absent and undecodable fields
+// stored as nil and returned as warnings, error only for key fields and wrong row
+func (s Schema) DecodeMapLenient(row any) (map[string]any, []error, error) {
+	return s.decode(row, true)
+}
+
+func (s Schema) decode(row any, lenient bool) (map[string]any, []error, error) {
+	data, ok := row.(map[string]any)
+	if !ok {
+		return nil, nil, fmt.Errorf("wrong row type %T", row)
+	}
+	val := make(map[string]any, len(s.Columns))
+	var (
+		absent   []string
+		warnings []error
+	)
+	for _, c := range s.Columns {
+		v, ok := data[c.Name]
+		if !ok {
+			if c.Key {
+				return val, nil, fmt.Errorf("absent key field %s", c.Name)
+			}
+			absent = append(absent, c.Name)
+			val[c.Name] = nil
+			continue
+		}
+		var err error
+		val[c.Name], err = c.Decode(v)
+		if err != nil {
+			if !lenient || c.Key {
+				return val, nil, err
+			}
+			warnings = append(warnings, err)
+		}
+	}
+	if len(absent) != 0 {
+		warnings = append(warnings, fmt.Errorf("absent %v fields", absent))
+	}
+	return val, warnings, nil
+}
+
+// Values column values of decoded row in schema order
+func (s Schema) Values(val map[string]any) []any {
+	values := make([]any, len(s.Columns))
+	for i := range s.Columns {
+		values[i] = val[s.Columns[i].Name]
+	}
+	return values
+}
+
+// Decode decode report row to column values in schema order
+func (s Schema) Decode(row any) ([]any, error) {
+	val, err := s.DecodeMap(row)
+	if err != nil {
+		return nil, err
+	}
+	return s.Values(val), nil
+}
+
+// InsertSQL insert statement of one row, values in schema order
+func (s Schema) InsertSQL() string {
+	placeholders := make([]string, len(s.Columns))
+	for i := range s.Columns {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+	}
+	return "INSERT INTO " + s.Table + " (" + strings.Join(s.ColumnNames(), ", ") + ")\n" +
+		"VALUES (" + strings.Join(placeholders, ", ") + ")"
+}
+
+// DDL create table statement
+func (s Schema) DDL() string {
+	var b strings.Builder
+	b.WriteString("-- Table: public." + s.Table + "\n\n")
+	b.WriteString("-- DROP TABLE IF EXISTS public." + s.Table + ";\n\n")
+	b.WriteString("CREATE TABLE IF NOT EXISTS public." + s.Table + "\n(\n")
+	var keys []string
+	for _, c := range s.Columns {
+		b.WriteString("    " + c.Name + " " + c.SQLType())
+		if c.Key || c.NotNull {
+			b.WriteString(" NOT NULL")
+		}
+		b.WriteString(",\n")
+		if c.Key {
+			keys = append(keys, c.Name)
+		}
+	}
+	b.WriteString("    updated_at timestamp with time zone NOT NULL DEFAULT now()")
+	if len(keys) != 0 {
+		b.WriteString(",\n    CONSTRAINT " + s.Table + "_pkey PRIMARY KEY (" + strings.Join(keys, ", ") + ")")
+	}
+	b.WriteString("\n    );\n")
+	return b.String()
+}
+
+// CallsReportSchema get.calls_report row stored in calls table,
+// nested arrays (tags, employees, scenario_operations...) stored as jsonb
+var CallsReportSchema = Schema{
+	Table: "calls",
+	Columns: []Column{
+		{Name: "id", Type: ColumnInt64, Key: true},
+		{Name: "communication_id", Type: ColumnInt64},
+		{Name: "start_time", Type: ColumnTime},
+		{Name: "finish_time", Type: ColumnTime},
+		{Name: "finish_reason", Type: ColumnString, Size: 100},
+		{Name: "direction", Type: ColumnString, Size: 3},
+		{Name: "is_lost", Type: ColumnBool},
+		{Name: "virtual_phone_number", Type: ColumnString, Size: 20},
+		{Name: "contact_phone_number", Type: ColumnString, Size: 20},
+		{Name: "first_answered_employee_id", Type: ColumnInt64},
+		{Name: "first_answered_employee_full_name", Type: ColumnString, Size: 250},
+		{Name: "first_talked_employee_id", Type: ColumnInt64},
+		{Name: "first_talked_employee_full_name", Type: ColumnString, Size: 250},
+		{Name: "last_answered_employee_id", Type: ColumnInt64},
+		{Name: "last_answered_employee_full_name", Type: ColumnString, Size: 250},
+		{Name: "scenario_id", Type: ColumnInt64},
+		{Name: "scenario_name", Type: ColumnString, Size: 250},
+		{Name: "source", Type: ColumnString, Size: 100},
+		{Name: "cpn_region_id", Type: ColumnInt64},
+		{Name: "cpn_region_name", Type: ColumnString, Size: 250},
+		{Name: "communication_number", Type: ColumnInt64},
+		{Name: "communication_page_url", Type: ColumnString},
+		{Name: "communication_type", Type: ColumnString, Size: 50},
+		{Name: "wait_duration", Type: ColumnDuration},
+		{Name: "total_wait_duration", Type: ColumnDuration},
+		{Name: "lost_call_processing_duration", Type: ColumnDuration},
+		{Name: "talk_duration", Type: ColumnDuration},
+		{Name: "clean_talk_duration", Type: ColumnDuration},
+		{Name: "total_duration", Type: ColumnDuration},
+		{Name: "postprocess_duration", Type: ColumnDuration},
+		{Name: "call_records", Type: ColumnJSON},
+		{Name: "wav_call_records", Type: ColumnJSON},
+		{Name: "full_record_file_link", Type: ColumnString},
+		{Name: "voice_mail_records", Type: ColumnJSON},
+		{Name: "ua_client_id", Type: ColumnString, Size: 100},
+		{Name: "ym_client_id", Type: ColumnString, Size: 100},
+		{Name: "sale_date", Type: ColumnTime},
+		{Name: "sale_cost", Type: ColumnFloat64},
+		{Name: "is_transfer", Type: ColumnBool},
+		{Name: "search_query", Type: ColumnString},
+		{Name: "search_engine", Type: ColumnString, Size: 250},
+		{Name: "referrer_domain", Type: ColumnString, Size: 250},
+		{Name: "referrer", Type: ColumnString},
+		{Name: "entrance_page", Type: ColumnString},
+		{Name: "gclid", Type: ColumnString, Size: 250},
+		{Name: "yclid", Type: ColumnString, Size: 250},
+		{Name: "ymclid", Type: ColumnString, Size: 250},
+		{Name: "ef_id", Type: ColumnString, Size: 250},
+		{Name: "channel", Type: ColumnString, Size: 100},
+		{Name: "last_answered_employee_rating", Type: ColumnInt64},
+		{Name: "site_domain_name", Type: ColumnString, Size: 250},
+		{Name: "site_id", Type: ColumnInt64},
+		{Name: "campaign_name", Type: ColumnString, Size: 250},
+		{Name: "campaign_id", Type: ColumnInt64},
+		{Name: "visit_other_campaign", Type: ColumnBool},
+		{Name: "visitor_id", Type: ColumnInt64},
+		{Name: "person_id", Type: ColumnInt64},
+		{Name: "visitor_type", Type: ColumnString, Size: 50},
+		{Name: "visitor_session_id", Type: ColumnInt64},
+		{Name: "visits_count", Type: ColumnInt64},
+		{Name: "visitor_first_campaign_id", Type: ColumnInt64},
+		{Name: "visitor_first_campaign_name", Type: ColumnString, Size: 250},
+		{Name: "visitor_city", Type: ColumnString, Size: 250},
+		{Name: "visitor_region", Type: ColumnString, Size: 250},
+		{Name: "visitor_country", Type: ColumnString, Size: 250},
+		{Name: "visitor_device", Type: ColumnString, Size: 50},
+		{Name: "call_api_request_id", Type: ColumnString, Size: 250},
+		{Name: "call_api_external_id", Type: ColumnString, Size: 250},
+		{Name: "contact_id", Type: ColumnInt64},
+		{Name: "contact_full_name", Type: ColumnString, Size: 250},
+		{Name: "utm_source", Type: ColumnString},
+		{Name: "utm_medium", Type: ColumnString},
+		{Name: "utm_term", Type: ColumnString},
+		{Name: "utm_content", Type: ColumnString},
+		{Name: "utm_campaign", Type: ColumnString},
+		{Name: "openstat_ad", Type: ColumnString},
+		{Name: "openstat_campaign", Type: ColumnString},
+		{Name: "openstat_service", Type: ColumnString},
+		{Name: "openstat_source", Type: ColumnString},
+		{Name: "eq_utm_source", Type: ColumnString},
+		{Name: "eq_utm_medium", Type: ColumnString},
+		{Name: "eq_utm_term", Type: ColumnString},
+		{Name: "eq_utm_content", Type: ColumnString},
+		{Name: "eq_utm_campaign", Type: ColumnString},
+		{Name: "eq_utm_referrer", Type: ColumnString},
+		{Name: "eq_utm_expid", Type: ColumnString},
+		{Name: "tags", Type: ColumnJSON},
+		{Name: "employees", Type: ColumnJSON},
+		{Name: "scenario_operations", Type: ColumnJSON},
+		{Name: "visitor_custom_properties", Type: ColumnJSON},
+		{Name: "segments", Type: ColumnJSON},
+		{Name: "attributes", Type: ColumnJSON},
+	},
+}
+
+// CallLegsReportSchema get.call_legs_report row stored in call_legs table
+var CallLegsReportSchema = Schema{
+	Table: "call_legs",
+	Columns: []Column{
+		{Name: "id", Type: ColumnInt64, Key: true},
+		{Name: "call_session_id", Type: ColumnInt64},
+		{Name: "start_time", Type: ColumnTime},
+		{Name: "connect_time", Type: ColumnTime},
+		{Name: "duration", Type: ColumnDuration},
+		{Name: "total_duration", Type: ColumnDuration},
+		{Name: "finish_reason", Type: ColumnString, Size: 100},
+		{Name: "finish_reason_description", Type: ColumnString, Size: 100},
+		{Name: "virtual_phone_number", Type: ColumnString, Size: 20},
+		{Name: "calling_phone_number", Type: ColumnString, Size: 20},
+		{Name: "called_phone_number", Type: ColumnString, Size: 20},
+		{Name: "direction", Type: ColumnString, Size: 3},
+		{Name: "is_transfered", Type: ColumnBool},
+		{Name: "is_operator", Type: ColumnBool},
+		{Name: "is_coach", Type: ColumnBool},
+		{Name: "is_failed", Type: ColumnBool},
+		{Name: "is_talked", Type: ColumnBool},
+		{Name: "employee_id", Type: ColumnInt64},
+		{Name: "employee_full_name", Type: ColumnString, Size: 250},
+		{Name: "employee_phone_number", Type: ColumnString, Size: 20},
+		{Name: "scenario_id", Type: ColumnInt64},
+		{Name: "scenario_name", Type: ColumnString, Size: 250},
+		{Name: "release_cause_code", Type: ColumnInt64},
+		{Name: "release_cause_description", Type: ColumnString, Size: 250},
+		{Name: "contact_id", Type: ColumnInt64},
+		{Name: "contact_full_name", Type: ColumnString, Size: 250},
+		{Name: "contact_phone_number", Type: ColumnString, Size: 20},
+		{Name: "action_id", Type: ColumnInt64},
+		{Name: "action_name", Type: ColumnString, Size: 250},
+		{Name: "group_id", Type: ColumnInt64},
+		{Name: "group_name", Type: ColumnString, Size: 250},
+	},
+}
@@ -0,0 +1,214 @@
+package uiscom
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSchema = Schema{
+	Table: "test",
+	Columns: []Column{
+		{Name: "id", Type: ColumnInt64, Key: true},
+		{Name: "name", Type: ColumnString, Size: 100},
+		{Name: "cost", Type: ColumnFloat64},
+		{Name: "is_lost", Type: ColumnBool},
+		{Name: "start_time", Type: ColumnTime, NotNull: true},
+		{Name: "talk_duration", Type: ColumnDuration},
+		{Name: "tags", Type: ColumnJSON},
+	},
+}
+
+// decodeRow report row decoded like API response, numbers as json.Number
+func decodeRow(t *testing.T, s string) any {
+	t.Helper()
+	var row any
+	d := json.NewDecoder(strings.NewReader(s))
+	d.UseNumber()
+	if err := d.Decode(&row); err != nil {
+		t.Fatal(err)
+	}
+	return row
+}
+
+func TestColumnDecode(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		column  Column
+		v       any
+		want    any
+		wantErr bool
+	}{
+		{name: "int64", column: Column{Type: ColumnInt64}, v: json.Number("42"), want: int64(42)},
+		{name: "int64 float", column: Column{Type: ColumnInt64}, v: json.Number("4.2"), wantErr: true},
+		{name: "int64 string", column: Column{Type: ColumnInt64}, v: "42", wantErr: true},
+		{name: "float64", column: Column{Type: ColumnFloat64}, v: json.Number("4.5"), want: 4.5},
+		{name: "string", column: Column{Type: ColumnString}, v: "abc", want: "abc"},
+		{name: "string number", column: Column{Type: ColumnString}, v: json.Number("123"), want: "123"},
+		{name: "bool", column: Column{Type: ColumnBool}, v: true, want: true},
+		{name: "bool string", column: Column{Type: ColumnBool}, v: "true", wantErr: true},
+		{name: "time", column: Column{Type: ColumnTime}, v: "2024-03-01 10:00:00", want: start},
+		{name: "time empty", column: Column{Type: ColumnTime}, v: "", want: nil},
+		{name: "time wrong", column: Column{Type: ColumnTime}, v: "yesterday", wantErr: true},
+		{name: "duration", column: Column{Type: ColumnDuration}, v: json.Number("1.5"), want: 1500 * time.Millisecond},
+		{name: "json", column: Column{Type: ColumnJSON}, v: []any{"a"}, want: []any{"a"}},
+		{name: "null", column: Column{Type: ColumnInt64}, v: nil, want: nil},
+		{name: "null not null", column: Column{Type: ColumnInt64, NotNull: true}, v: nil, wantErr: true},
+		{name: "null key", column: Column{Type: ColumnInt64, Key: true}, v: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.column.Name = "field"
+			got, err := tt.column.Decode(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, expected error %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.HasPrefix(err.Error(), "field: ") {
+				t.Errorf("error %q without field name", err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, expected %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaDecodeMap(t *testing.T) {
+	tests := []struct {
+		name         string
+		row          string
+		want         map[string]any
+		wantErr      bool
+		lenientErr   bool
+		lenientWarns int
+	}{
+		{
+			name: "complete",
+			row:  `{"id":1,"name":"a","cost":1.5,"is_lost":false,"start_time":"2024-03-01 10:00:00","talk_duration":30,"tags":[]}`,
+			want: map[string]any{
+				"id": int64(1), "name": "a", "cost": 1.5, "is_lost": false,
+				"start_time":    time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+				"talk_duration": 30 * time.Second, "tags": []any{},
+			},
+		},
+		{
+			name:         "absent field",
+			row:          `{"id":1,"name":"a","cost":1.5,"is_lost":false,"start_time":"2024-03-01 10:00:00","tags":null}`,
+			wantErr:      true,
+			lenientWarns: 1,
+		},
+		{
+			name:         "wrong type and absent fields",
+			row:          `{"id":1,"name":"a","cost":"1.5","start_time":"2024-03-01 10:00:00"}`,
+			wantErr:      true,
+			lenientWarns: 2,
+		},
+		{
+			name:    "null not null",
+			row:     `{"id":1,"name":"a","cost":1.5,"is_lost":false,"start_time":null,"talk_duration":30,"tags":[]}`,
+			wantErr: true,
+			// null of not null field is warning in lenient mode
+			lenientWarns: 1,
+		},
+		{
+			name:       "absent key",
+			row:        `{"name":"a"}`,
+			wantErr:    true,
+			lenientErr: true,
+		},
+		{
+			name:       "wrong key",
+			row:        `{"id":"1","name":"a"}`,
+			wantErr:    true,
+			lenientErr: true,
+		},
+		{
+			name:       "wrong row",
+			row:        `[1]`,
+			wantErr:    true,
+			lenientErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := decodeRow(t, tt.row)
+			got, err := testSchema.DecodeMap(row)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, expected error %v", err, tt.wantErr)
+			}
+			if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, expected %#v", got, tt.want)
+			}
+
+			lenient, warnings, err := testSchema.DecodeMapLenient(row)
+			if (err != nil) != tt.lenientErr {
+				t.Fatalf("lenient error %v, expected error %v", err, tt.lenientErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(warnings) != tt.lenientWarns {
+				t.Errorf("lenient warnings %v, expected %d", warnings, tt.lenientWarns)
+			}
+			if len(lenient) != len(testSchema.Columns) {
+				t.Errorf("lenient decoded %d columns, expected %d", len(lenient), len(testSchema.Columns))
+			}
+		})
+	}
+}
+
+func TestSchemaValues(t *testing.T) {
+	s := Schema{Table: "test", Columns: testSchema.Columns[:3]}
+	got := s.Values(map[string]any{"cost": 1.5, "id": int64(1), "other": "x"})
+	want := []any{int64(1), nil, 1.5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, expected %#v", got, want)
+	}
+	if s.Keys() != 1 {
+		t.Errorf("keys %d, expected 1", s.Keys())
+	}
+}
+
+func TestSchemaInsertSQL(t *testing.T) {
+	s := Schema{Table: "test", Columns: testSchema.Columns[:3]}
+	want := "INSERT INTO test (id, name, cost)\nVALUES ($1, $2, $3)"
+	if got := s.InsertSQL(); got != want {
+		t.Errorf("got\n%s\nexpected\n%s", got, want)
+	}
+}
+
+func TestSchemaDDL(t *testing.T) {
+	s := Schema{
+		Table: "test",
+		Columns: []Column{
+			{Name: "id", Type: ColumnInt64, Key: true},
+			{Name: "date_from", Type: ColumnTime, Key: true},
+			{Name: "name", Type: ColumnString, Size: 100},
+			{Name: "comment", Type: ColumnString, NotNull: true},
+			{Name: "talk_duration", Type: ColumnDuration},
+			{Name: "tags", Type: ColumnJSON},
+		},
+	}
+	want := `-- Table: public.test
+
+-- DROP TABLE IF EXISTS public.test;
+
+CREATE TABLE IF NOT EXISTS public.test
+(
+    id bigint NOT NULL,
+    date_from timestamp without time zone NOT NULL,
+    name character varying(100) COLLATE pg_catalog."default",
+    comment text COLLATE pg_catalog."default" NOT NULL,
+    talk_duration interval,
+    tags jsonb,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT test_pkey PRIMARY KEY (id, date_from)
+    );
+`
+	if got := s.DDL(); got != want {
+		t.Errorf("got\n%s\nexpected\n%s", got, want)
+	}
+}
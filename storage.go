@@ -0,0 +1,204 @@
+package uiscom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ErrNotExist storage object not exist
+var ErrNotExist = errors.New("object not exist")
+
+// Storage records storage, key is slash separated relative path
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Stat return object size or ErrNotExist
+	Stat(ctx context.Context, key string) (int64, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalStorage storage in local filesystem Root folder
+type LocalStorage struct {
+	Root string
+}
+
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{Root: root}
+}
+
+func (s *LocalStorage) Path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("wrong key %q", key)
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(clean)), nil
+}
+
+// Put write into temp file and atomically rename
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	filename, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("error creating destination directory: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	n, err := io.Copy(f, readerWithContext(ctx, r))
+	if err != nil {
+		return err
+	}
+	if size >= 0 && n != size {
+		return fmt.Errorf("%s: written %d of %d bytes", key, n, size)
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
+
+func (s *LocalStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	filename, err := s.Path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	return f, err
+}
+
+func (s *LocalStorage) Stat(_ context.Context, key string) (int64, error) {
+	filename, err := s.Path(key)
+	if err != nil {
+		return 0, err
+	}
+	st, err := os.Stat(filename)
+	if os.IsNotExist(err) {
+		return 0, ErrNotExist
+	}
+	if err != nil {
+		return 0, err
+	}
+	return st.Size(), nil
+}
+
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	filename, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filename)
+	if os.IsNotExist(err) {
+		return ErrNotExist
+	}
+	return err
+}
+
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+func readerWithContext(ctx context.Context, r io.Reader) io.Reader {
+	return ctxReader{ctx: ctx, r: r}
+}
+
+// DefaultPathTemplate records layout YYYY/MM/DD/[out_|vm_]communication_id/filename
+const DefaultPathTemplate = `{{date .start_time "2006/01/02"}}/` +
+	`{{if eq .record_kind "voice_mail"}}vm_{{else if eq .direction "out"}}out_{{end}}{{.communication_id}}/` +
+	`{{.filename}}`
+
+var defaultPathTemplate = func() *PathTemplate {
+	t, err := NewPathTemplate(DefaultPathTemplate)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}()
+
+// PathTemplate storage key template, executed with calls report row fields and
+// "record_kind", "record_id", "filename" values. Function "date" format time or
+// API datetime string by Go layout.
+type PathTemplate struct {
+	t *template.Template
+}
+
+func NewPathTemplate(text string) (*PathTemplate, error) {
+	t, err := template.New("path").Option("missingkey=zero").Funcs(template.FuncMap{
+		"date": templateDate,
+	}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &PathTemplate{t: t}, nil
+}
+
+func (p *PathTemplate) Execute(row map[string]any, record Record, filename string) (string, error) {
+	data := make(map[string]any, len(row)+3)
+	for k, v := range row {
+		data[k] = v
+	}
+	if _, ok := data["communication_id"]; !ok {
+		data["communication_id"] = record.CommunicationID
+	}
+	data["record_kind"] = record.Kind.String()
+	data["record_id"] = record.ID
+	data["filename"] = filename
+
+	var b strings.Builder
+	if err := p.t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	// missing field of map printed as "<no value>" even with missingkey=zero
+	key := path.Clean("/" + strings.ReplaceAll(b.String(), "<no value>", ""))
+	if key == "/" {
+		return "", fmt.Errorf("path template give empty key")
+	}
+	return key[1:], nil
+}
+
+func templateDate(v any, layout string) (string, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format(layout), nil
+	case DateTime:
+		return v.Format(layout), nil
+	case string:
+		t, err := StringToTime(v)
+		if err != nil {
+			return "", err
+		}
+		return t.Format(layout), nil
+	default:
+		return "", fmt.Errorf("date: wrong type %T", v)
+	}
+}
@@ -0,0 +1,110 @@
+package uiscom
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPathTemplate(t *testing.T) {
+	record := Record{CommunicationID: 42, Kind: RecordKindTalk, ID: "abc"}
+	row := map[string]any{"start_time": "2024-03-01 10:00:00", "direction": "in", "communication_id": int64(42)}
+	tests := []struct {
+		name     string
+		template string
+		row      map[string]any
+		record   Record
+		want     string
+		wantErr  bool
+	}{
+		{name: "default", template: DefaultPathTemplate, row: row, record: record, want: "2024/03/01/42/record.mp3"},
+		{
+			name:     "default outgoing",
+			template: DefaultPathTemplate,
+			row:      map[string]any{"start_time": "2024-03-01 10:00:00", "direction": "out"},
+			record:   record,
+			want:     "2024/03/01/out_42/record.mp3",
+		},
+		{
+			name:     "default voice mail",
+			template: DefaultPathTemplate,
+			row:      row,
+			record:   Record{CommunicationID: 42, Kind: RecordKindVoiceMail, ID: "abc"},
+			want:     "2024/03/01/vm_42/record.mp3",
+		},
+		{
+			name:     "time value",
+			template: `{{date .start_time "2006-01"}}/{{.record_kind}}-{{.record_id}}`,
+			row:      map[string]any{"start_time": time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)},
+			record:   record,
+			want:     "2024-03/talk-abc",
+		},
+		{
+			name:     "parent folder cleaned",
+			template: `../../{{.filename}}`,
+			record:   record,
+			want:     "record.mp3",
+		},
+		{name: "missing field blank", template: `{{.missing}}/{{.filename}}`, record: record, want: "record.mp3"},
+		{name: "empty key", template: `{{.missing}}`, record: record, wantErr: true},
+		{name: "wrong date", template: `{{date .start_time "2006"}}`, row: map[string]any{"start_time": "yesterday"}, record: record, wantErr: true},
+		{name: "date without time", template: `{{date .start_time "2006"}}`, record: record, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPathTemplate(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.Execute(tt.row, tt.record, "record.mp3")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, expected error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, expected %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewPathTemplate(`{{.start_time`); err == nil {
+		t.Error("wrong template parsed")
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocalStorage(t.TempDir())
+	putObject(t, s, "2024/03/01/record.mp3", "content")
+
+	if size, err := s.Stat(ctx, "2024/03/01/record.mp3"); err != nil || size != 7 {
+		t.Errorf("stat %d, %v, expected 7", size, err)
+	}
+	r, err := s.Get(ctx, "2024/03/01/record.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(b) != "content" {
+		t.Errorf("get %q, %v, expected content", b, err)
+	}
+	if temp, _ := filepath.Glob(filepath.Join(s.Root, "2024", "03", "01", ".*.tmp")); len(temp) != 0 {
+		t.Errorf("temp files left: %v", temp)
+	}
+
+	if err := s.Delete(ctx, "2024/03/01/record.mp3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Stat(ctx, "2024/03/01/record.mp3"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("stat deleted %v, expected ErrNotExist", err)
+	}
+	if err := s.Delete(ctx, "2024/03/01/record.mp3"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("delete deleted %v, expected ErrNotExist", err)
+	}
+	if _, err := s.Path("/../"); err == nil {
+		t.Error("empty key accepted")
+	}
+}
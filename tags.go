@@ -0,0 +1,119 @@
+package uiscom
+
+import (
+	"context"
+	"time"
+)
+
+// TagsResponse get.tags result
+type TagsResponse struct {
+	Data     []Tag    `json:"data"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// Tag
+//
+//	{
+//	 "id": "number",
+//	 "name": "string",
+//	 "type": "string",
+//	 "is_system": "boolean",
+//	 "tag_group_id": "number",
+//	 "tag_group_name": "string"
+//	}
+type Tag struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	Type         string  `json:"type"`
+	IsSystem     bool    `json:"is_system"`
+	TagGroupID   *int64  `json:"tag_group_id"`
+	TagGroupName *string `json:"tag_group_name"`
+}
+
+// TagParams create.tags/update.tags parameters, zero values not sent
+type TagParams struct {
+	ID         int64  `json:"id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	TagGroupID int64  `json:"tag_group_id,omitempty"`
+}
+
+type CommunicationType string
+
+func (t CommunicationType) String() string {
+	return string(t)
+}
+
+const (
+	CommunicationTypeCall           = CommunicationType("call")
+	CommunicationTypeChat           = CommunicationType("chat")
+	CommunicationTypeOfflineMessage = CommunicationType("offline_message")
+	CommunicationTypeGoal           = CommunicationType("goal")
+)
+
+func (c Client) GetTags(ctx context.Context, userID int, limit, offset int, filter *Filter, fields ...Field) (*TagsResponse, error) {
+	var resp TagsResponse
+	err := c.callFor(ctx, &resp, "get.tags", c.listParams(userID, limit, offset, filter, fields))
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c Client) CreateTag(ctx context.Context, userID int, tag TagParams) (int64, error) {
+	return c.callForID(ctx, "create.tags", userID, tag)
+}
+
+func (c Client) UpdateTag(ctx context.Context, userID int, tag TagParams) (int64, error) {
+	return c.callForID(ctx, "update.tags", userID, tag)
+}
+
+func (c Client) DeleteTag(ctx context.Context, userID int, id int64) (int64, error) {
+	return c.callForID(ctx, "delete.tags", userID, map[string]any{"id": id})
+}
+
+// SetTagCommunications tag communication, communicationType default CommunicationTypeCall
+func (c Client) SetTagCommunications(ctx context.Context, userID int, communicationID int64, communicationType CommunicationType, tagID int64) error {
+	return c.tagCommunications(ctx, "set.tag_communications", userID, communicationID, communicationType, tagID)
+}
+
+// UnsetTagCommunications remove tag from communication, communicationType default CommunicationTypeCall
+func (c Client) UnsetTagCommunications(ctx context.Context, userID int, communicationID int64, communicationType CommunicationType, tagID int64) error {
+	return c.tagCommunications(ctx, "unset.tag_communications", userID, communicationID, communicationType, tagID)
+}
+
+// SetTagSales tag communication as sale with sale date and cost
+func (c Client) SetTagSales(ctx context.Context, userID int, communicationID int64, communicationType CommunicationType, tagID int64, saleDate time.Time, saleCost float64) error {
+	if communicationType == "" {
+		communicationType = CommunicationTypeCall
+	}
+	params := map[string]any{
+		"access_token":       c.AccessToken,
+		"communication_id":   communicationID,
+		"communication_type": communicationType,
+		"tag_id":             tagID,
+		"date":               TimeToString(saleDate),
+		"transaction_value":  saleCost,
+	}
+	if userID >= 0 {
+		params["user_id"] = userID
+	}
+	_, err := c.call(ctx, "set.tag_sales", params)
+	return err
+}
+
+func (c Client) tagCommunications(ctx context.Context, method string, userID int, communicationID int64, communicationType CommunicationType, tagID int64) error {
+	if communicationType == "" {
+		communicationType = CommunicationTypeCall
+	}
+	params := map[string]any{
+		"access_token":       c.AccessToken,
+		"communication_id":   communicationID,
+		"communication_type": communicationType,
+		"tag_id":             tagID,
+	}
+	if userID >= 0 {
+		params["user_id"] = userID
+	}
+	_, err := c.call(ctx, method, params)
+	return err
+}
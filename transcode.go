@@ -0,0 +1,453 @@
+package uiscom
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/hajimehoshi/go-mp3"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type AudioFormat string
+
+func (f AudioFormat) String() string {
+	return string(f)
+}
+
+const (
+	AudioFormatWAV  = AudioFormat("wav")
+	AudioFormatOpus = AudioFormat("opus")
+	AudioFormatFLAC = AudioFormat("flac")
+)
+
+func (f AudioFormat) ContentType() string {
+	switch f {
+	case AudioFormatWAV:
+		return "audio/wav"
+	case AudioFormatOpus:
+		return "audio/ogg"
+	case AudioFormatFLAC:
+		return "audio/flac"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Channel variants of split stereo talk record
+const (
+	ChannelOperator = "operator"
+	ChannelCustomer = "customer"
+)
+
+// TranscodedFile local transcoder output, Channel blank for not split record
+type TranscodedFile struct {
+	Path    string
+	Format  AudioFormat
+	Channel string
+}
+
+// Transcoder convert local record file of kind, outputs created next to src and removed by caller
+type Transcoder interface {
+	Transcode(ctx context.Context, src string, kind RecordKind) ([]TranscodedFile, error)
+}
+
+// splitSource split only stereo talk records, voice mail and mono records have one speaker
+func splitSource(src string, kind RecordKind) (bool, error) {
+	if kind != RecordKindTalk {
+		return false, nil
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	channels, err := AudioChannels(f, st.Size())
+	if err != nil {
+		return false, err
+	}
+	return channels == 2, nil
+}
+
+// DefaultMaxPCMSize decoded record samples limit of PCMTranscoder,
+// about 9 hours of 8 kHz mono or 50 minutes of 44.1 kHz stereo
+const DefaultMaxPCMSize = 512 << 20
+
+// PCMTranscoder pure Go transcoder of MP3 and PCM WAV records into 16 bit PCM WAV,
+// whole record decoded in memory. Downsampling is windowed sinc low-pass filter
+// and linear interpolation, use ExternalTranscoder for better quality and long records.
+type PCMTranscoder struct {
+	// SampleRate output sample rate, 0 keep source rate
+	SampleRate int
+	// MaxPCMSize decoded samples size limit in bytes, default DefaultMaxPCMSize
+	MaxPCMSize int64
+	// Mono downmix channels, ignored if Split
+	Mono bool
+	// Split stereo talk record into operator and customer mono files,
+	// other records transcoded into one mono file
+	Split bool
+	// OperatorChannel 0 left, 1 right
+	OperatorChannel int
+}
+
+func (t PCMTranscoder) Transcode(ctx context.Context, src string, kind RecordKind) ([]TranscodedFile, error) {
+	split, mono := t.Split, t.Mono
+	if split {
+		var err error
+		if split, err = splitSource(src, kind); err != nil {
+			return nil, err
+		}
+		mono = !split
+	}
+	limit := t.MaxPCMSize
+	if limit <= 0 {
+		limit = DefaultMaxPCMSize
+	}
+	left, right, rate, err := decodePCM(src, limit)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if t.SampleRate > 0 && t.SampleRate != rate {
+		left = resample(left, rate, t.SampleRate)
+		right = resample(right, rate, t.SampleRate)
+		rate = t.SampleRate
+	}
+
+	base := strings.TrimSuffix(src, filepath.Ext(src))
+	if split {
+		operator, customer := left, right
+		if t.OperatorChannel == 1 {
+			operator, customer = right, left
+		}
+		files := []TranscodedFile{
+			{Path: base + "." + ChannelOperator + ".wav", Format: AudioFormatWAV, Channel: ChannelOperator},
+			{Path: base + "." + ChannelCustomer + ".wav", Format: AudioFormatWAV, Channel: ChannelCustomer},
+		}
+		if err := writeWAV(files[0].Path, rate, operator); err != nil {
+			return nil, err
+		}
+		if err := writeWAV(files[1].Path, rate, customer); err != nil {
+			_ = os.Remove(files[0].Path)
+			return nil, err
+		}
+		return files, nil
+	}
+
+	file := TranscodedFile{Path: base + ".transcoded.wav", Format: AudioFormatWAV}
+	if mono {
+		mono := make([]int16, len(left))
+		for i := range left {
+			mono[i] = int16((int32(left[i]) + int32(right[i])) / 2)
+		}
+		return []TranscodedFile{file}, writeWAV(file.Path, rate, mono)
+	}
+	return []TranscodedFile{file}, writeWAV(file.Path, rate, left, right)
+}
+
+// decodePCM decode MP3 or 16 bit PCM WAV into left and right channels, mono source duplicated,
+// error if decoded samples bigger than limit bytes
+func decodePCM(src string, limit int64) (left, right []int16, rate int, err error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 12)
+	if _, err := io.ReadFull(f, head); err != nil {
+		return nil, nil, 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, 0, err
+	}
+
+	var (
+		samples  []byte
+		channels = 2
+	)
+	if bytes.Equal(head[0:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WAVE")) {
+		samples, rate, channels, err = readWAV(f, limit)
+	} else {
+		var d *mp3.Decoder
+		d, err = mp3.NewDecoder(bufio.NewReader(f))
+		if err == nil {
+			rate = d.SampleRate()
+			samples, err = readLimited(d, limit)
+		}
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	frames := len(samples) / 2 / channels
+	left = make([]int16, frames)
+	right = make([]int16, frames)
+	for i := 0; i < frames; i++ {
+		left[i] = int16(binary.LittleEndian.Uint16(samples[i*2*channels:]))
+		if channels == 1 {
+			right[i] = left[i]
+		} else {
+			right[i] = int16(binary.LittleEndian.Uint16(samples[i*2*channels+2:]))
+		}
+	}
+	return left, right, rate, nil
+}
+
+func readWAV(r io.Reader, limit int64) (data []byte, rate, channels int, err error) {
+	// header chunks before data allowed over limit
+	b, err := readLimited(r, limit+1<<16)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	var bits int
+	for offset := 12; offset+8 <= len(b); {
+		id := string(b[offset : offset+4])
+		length := int(binary.LittleEndian.Uint32(b[offset+4 : offset+8]))
+		body := b[offset+8:]
+		if length > len(body) {
+			length = len(body)
+		}
+		switch id {
+		case "fmt ":
+			if length < 16 {
+				return nil, 0, 0, ErrUnknownAudioFormat
+			}
+			if binary.LittleEndian.Uint16(body[0:2]) != 1 {
+				return nil, 0, 0, errors.New("only PCM WAV supported")
+			}
+			channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			rate = int(binary.LittleEndian.Uint32(body[4:8]))
+			bits = int(binary.LittleEndian.Uint16(body[14:16]))
+		case "data":
+			if bits != 16 || (channels != 1 && channels != 2) {
+				return nil, 0, 0, fmt.Errorf("unsupported WAV %d bits %d channels", bits, channels)
+			}
+			if int64(length) > limit {
+				return nil, 0, 0, fmt.Errorf("decoded record bigger than %d bytes", limit)
+			}
+			return body[:length], rate, channels, nil
+		}
+		offset += 8 + length + length%2
+	}
+	return nil, 0, 0, ErrUnknownAudioFormat
+}
+
+// readLimited read all or error if more than limit bytes
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > limit {
+		return nil, fmt.Errorf("decoded record bigger than %d bytes", limit)
+	}
+	return b, nil
+}
+
+// resample linear interpolation, low-pass filtered before downsampling against aliasing
+func resample(in []int16, from, to int) []int16 {
+	if len(in) == 0 || from <= 0 || to <= 0 {
+		return in
+	}
+	if to < from {
+		// cutoff a bit below output Nyquist frequency for filter transition band
+		in = lowPass(in, 0.45*float64(to)/float64(from))
+	}
+	n := int(int64(len(in)) * int64(to) / int64(from))
+	out := make([]int16, n)
+	for i := range out {
+		pos := float64(i) * float64(from) / float64(to)
+		j := int(pos)
+		if j+1 >= len(in) {
+			out[i] = in[len(in)-1]
+			continue
+		}
+		frac := pos - float64(j)
+		out[i] = int16(float64(in[j])*(1-frac) + float64(in[j+1])*frac)
+	}
+	return out
+}
+
+// lowPassTaps windowed sinc filter length, odd for symmetric filter
+const lowPassTaps = 63
+
+// lowPass Hamming windowed sinc FIR filter, cutoff relative to sample rate
+func lowPass(in []int16, cutoff float64) []int16 {
+	const half = lowPassTaps / 2
+	kernel := make([]float64, lowPassTaps)
+	var sum float64
+	for i := range kernel {
+		x := float64(i - half)
+		k := 2 * cutoff
+		if x != 0 {
+			k = math.Sin(2*math.Pi*cutoff*x) / (math.Pi * x)
+		}
+		k *= 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(lowPassTaps-1))
+		kernel[i] = k
+		sum += k
+	}
+	// unity gain at zero frequency
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	out := make([]int16, len(in))
+	for i := range in {
+		var v float64
+		for j, k := range kernel {
+			n := i + j - half
+			if n < 0 || n >= len(in) {
+				continue
+			}
+			v += float64(in[n]) * k
+		}
+		out[i] = clampInt16(v)
+	}
+	return out
+}
+
+func clampInt16(v float64) int16 {
+	switch {
+	case v > math.MaxInt16:
+		return math.MaxInt16
+	case v < math.MinInt16:
+		return math.MinInt16
+	default:
+		return int16(math.Round(v))
+	}
+}
+
+// writeWAV 16 bit PCM WAV with one or two channels, partial file removed on error
+func writeWAV(filename string, rate int, channels ...[]int16) (err error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(filename)
+		}
+	}()
+	defer f.Close()
+
+	frames := len(channels[0])
+	dataSize := frames * 2 * len(channels)
+	w := bufio.NewWriter(f)
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(36+dataSize))
+	copy(header[8:16], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], 1)
+	binary.LittleEndian.PutUint16(header[22:24], uint16(len(channels)))
+	binary.LittleEndian.PutUint32(header[24:28], uint32(rate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(rate*2*len(channels)))
+	binary.LittleEndian.PutUint16(header[32:34], uint16(2*len(channels)))
+	binary.LittleEndian.PutUint16(header[34:36], 16)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], uint32(dataSize))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	sample := make([]byte, 2)
+	for i := 0; i < frames; i++ {
+		for c := range channels {
+			binary.LittleEndian.PutUint16(sample, uint16(channels[c][i]))
+			if _, err := w.Write(sample); err != nil {
+				return err
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// ExternalTranscoder transcoder by external ffmpeg compatible encoder
+type ExternalTranscoder struct {
+	// Command default "ffmpeg"
+	Command string
+	Format  AudioFormat
+	// SampleRate output sample rate, 0 keep source rate
+	SampleRate int
+	// Mono downmix channels, ignored if Split
+	Mono bool
+	// Split stereo talk record into operator and customer mono files,
+	// other records transcoded into one mono file
+	Split bool
+	// OperatorChannel 0 left, 1 right
+	OperatorChannel int
+}
+
+func (t ExternalTranscoder) Transcode(ctx context.Context, src string, kind RecordKind) ([]TranscodedFile, error) {
+	split, mono := t.Split, t.Mono
+	if split {
+		var err error
+		if split, err = splitSource(src, kind); err != nil {
+			return nil, err
+		}
+		mono = !split
+	}
+	command := t.Command
+	if command == "" {
+		command = "ffmpeg"
+	}
+	format := t.Format
+	if format == "" {
+		format = AudioFormatWAV
+	}
+	var output []string
+	if t.SampleRate > 0 {
+		output = append(output, "-ar", strconv.Itoa(t.SampleRate))
+	}
+
+	base := strings.TrimSuffix(src, filepath.Ext(src))
+	args := []string{"-hide_banner", "-loglevel", "error", "-y", "-i", src}
+	var files []TranscodedFile
+	if split {
+		operator, customer := "[l]", "[r]"
+		if t.OperatorChannel == 1 {
+			operator, customer = customer, operator
+		}
+		files = []TranscodedFile{
+			{Path: base + "." + ChannelOperator + "." + format.String(), Format: format, Channel: ChannelOperator},
+			{Path: base + "." + ChannelCustomer + "." + format.String(), Format: format, Channel: ChannelCustomer},
+		}
+		args = append(args, "-filter_complex", "[0:a]channelsplit=channel_layout=stereo[l][r]")
+		args = append(append(append(args, "-map", operator), output...), files[0].Path)
+		args = append(append(append(args, "-map", customer), output...), files[1].Path)
+	} else {
+		files = []TranscodedFile{{Path: base + ".transcoded." + format.String(), Format: format}}
+		if mono {
+			output = append(output, "-ac", "1")
+		}
+		args = append(append(args, output...), files[0].Path)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		for i := range files {
+			_ = os.Remove(files[i].Path)
+		}
+		return nil, fmt.Errorf("%s: %w %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return files, nil
+}
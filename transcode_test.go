@@ -0,0 +1,117 @@
+package uiscom
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// sine tone samples of amplitude 10000
+func sine(freq float64, rate, n int) []int16 {
+	out := make([]int16, n)
+	for i := range out {
+		out[i] = int16(10000 * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return out
+}
+
+// rms root mean square of samples without filter edges
+func rms(in []int16) float64 {
+	in = in[len(in)/10 : len(in)-len(in)/10]
+	var sum float64
+	for _, v := range in {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(sum / float64(len(in)))
+}
+
+func TestResample(t *testing.T) {
+	const full = 10000 / math.Sqrt2
+	tests := []struct {
+		name     string
+		freq     float64
+		from, to int
+		min, max float64
+	}{
+		{name: "pass band downsampling", freq: 1000, from: 44100, to: 8000, min: 0.95 * full, max: 1.05 * full},
+		// 6 kHz tone aliased into 2 kHz without low-pass filter
+		{name: "stop band downsampling", freq: 6000, from: 44100, to: 8000, max: 0.05 * full},
+		{name: "upsampling", freq: 1000, from: 8000, to: 16000, min: 0.95 * full, max: 1.05 * full},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := resample(sine(tt.freq, tt.from, tt.from), tt.from, tt.to)
+			if len(out) != tt.to {
+				t.Fatalf("samples %d, expected %d", len(out), tt.to)
+			}
+			if got := rms(out); got < tt.min || got > tt.max {
+				t.Errorf("rms %.0f, expected between %.0f and %.0f", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestPCMTranscoder(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "record.wav")
+	left, right := sine(1000, 16000, 16000), sine(500, 16000, 16000)
+	if err := writeWAV(src, 16000, left, right); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		t        PCMTranscoder
+		kind     RecordKind
+		channels []string
+	}{
+		{name: "split talk", t: PCMTranscoder{SampleRate: 8000, Split: true}, kind: RecordKindTalk, channels: []string{ChannelOperator, ChannelCustomer}},
+		{name: "split voice mail into mono", t: PCMTranscoder{SampleRate: 8000, Split: true}, kind: RecordKindVoiceMail, channels: []string{""}},
+		{name: "stereo", t: PCMTranscoder{}, kind: RecordKindTalk, channels: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := tt.t.Transcode(context.Background(), src, tt.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != len(tt.channels) {
+				t.Fatalf("files %v, expected channels %v", files, tt.channels)
+			}
+			for i, f := range files {
+				if f.Channel != tt.channels[i] {
+					t.Errorf("file %s channel %q, expected %q", f.Path, f.Channel, tt.channels[i])
+				}
+				l, r, rate, err := decodePCM(f.Path, DefaultMaxPCMSize)
+				if err != nil {
+					t.Fatal(err)
+				}
+				wantRate := tt.t.SampleRate
+				if wantRate == 0 {
+					wantRate = 16000
+				}
+				if rate != wantRate || len(l) != wantRate || len(r) != wantRate {
+					t.Errorf("file %s rate %d samples %d, expected %d", f.Path, rate, len(l), wantRate)
+				}
+				_ = os.Remove(f.Path)
+			}
+		})
+	}
+}
+
+func TestPCMTranscoderLimit(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "record.wav")
+	if err := writeWAV(src, 8000, sine(1000, 8000, 8000)); err != nil {
+		t.Fatal(err)
+	}
+	files, err := PCMTranscoder{MaxPCMSize: 1000}.Transcode(context.Background(), src, RecordKindVoiceMail)
+	if err == nil {
+		t.Fatalf("record over limit transcoded into %v", files)
+	}
+	if out, _ := filepath.Glob(filepath.Join(dir, "record.*.wav")); len(out) != 0 {
+		t.Errorf("output files left: %v", out)
+	}
+}
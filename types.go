@@ -329,3 +329,25 @@ var GetCallLegsReportResponseParametersFields = []Field{
 	"group_id",
 	"group_name",
 }
+
+var GetFinancialCallLegsReportResponseParametersFields = []Field{
+	"id",
+	"call_session_id",
+	"start_time",
+	"direction",
+	"virtual_phone_number",
+	"calling_phone_number",
+	"called_phone_number",
+	"employee_id",
+	"employee_full_name",
+	"duration",
+	"total_duration",
+	"billing_duration",
+	"tariff",
+	"tariff_price",
+	"cost",
+	"currency",
+	"is_operator",
+	"finish_reason",
+	"destination_zone_name",
+}
@@ -0,0 +1,348 @@
+package uiscom
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type CommunicationSource string
+
+const (
+	CommunicationSourceNotification = CommunicationSource("notification")
+	CommunicationSourcePoll         = CommunicationSource("poll")
+)
+
+// Communication new or updated communication from Watcher.
+// Event is set for notifications, Data is calls report row for polling.
+type Communication struct {
+	// ID communication_id
+	ID      int64
+	Source  CommunicationSource
+	Updated bool
+	Event   *Event
+	Data    map[string]any
+}
+
+// Watcher emits new and updated calls from notifications with polling GetCalls fallback,
+// communications de-duplicated by communication_id and call state same for both sources:
+// started, answered or finished with talk duration and lost flag.
+// Both sources keyed by communication_id only, notification template must send
+// communication_id, talk_duration and is_lost, notifications without communication_id ignored
+// and their calls emitted by polling.
+type Watcher struct {
+	Client        *Client
+	Notifications *NotificationHandler
+
+	// UserID -1 for not send
+	UserID int
+	// Fields calls report fields, watcherFields added if missing
+	Fields []Field
+	// From start of the first polling window, default time now - Overlap
+	From time.Time
+	// Overlap moving window back for late arriving data, default 15 minutes
+	Overlap time.Duration
+	// MaxCallDuration not finished calls polled until finish or this time after start, default 4 hours
+	MaxCallDuration time.Duration
+	// PollInterval polling interval when notifications are missing, default 1 minute
+	PollInterval time.Duration
+	// ReconcileInterval polling interval when notifications are received, default 10 minutes
+	ReconcileInterval time.Duration
+	// NotificationTimeout notifications considered missing after it without events, default 5 minutes
+	NotificationTimeout time.Duration
+	// Errors optional, receive polling errors, watcher continue with next poll
+	Errors chan<- error
+
+	mu               sync.Mutex
+	seen             map[int64]seenCommunication
+	lastNotification time.Time
+}
+
+type seenCommunication struct {
+	state     callState
+	startTime time.Time
+}
+
+// callState communication state comparable between notification and report row,
+// stages only go forward, so poll of answered call not emitted as update
+type callState struct {
+	stage        int
+	talkDuration int64
+	isLost       bool
+}
+
+const (
+	callStageStarted = iota + 1
+	callStageAnswered
+	callStageFinished
+)
+
+func eventCallState(e Event) callState {
+	s := callState{stage: callStageStarted, talkDuration: int64(e.TalkDuration / time.Second), isLost: e.IsLost}
+	switch {
+	case e.Type == EventCallFinished || e.Type == EventCallLost || !e.FinishTime.IsZero():
+		s.stage = callStageFinished
+		s.isLost = s.isLost || e.Type == EventCallLost
+	case e.Type == EventCallAnswered:
+		s.stage = callStageAnswered
+	}
+	return s
+}
+
+func rowCallState(row map[string]any) callState {
+	s := callState{stage: callStageStarted}
+	if v, ok := row["finish_time"]; ok && v != nil && fmt.Sprint(v) != "" {
+		s.stage = callStageFinished
+	}
+	s.talkDuration, _ = rowInt64(row["talk_duration"])
+	s.isLost, _ = row["is_lost"].(bool)
+	return s
+}
+
+// watcherFields calls report fields of communication key and call state
+var watcherFields = []Field{"communication_id", "start_time", "finish_time", "talk_duration", "is_lost"}
+
+func NewWatcher(client *Client, notifications *NotificationHandler) *Watcher {
+	return &Watcher{
+		Client:        client,
+		Notifications: notifications,
+		UserID:        -1,
+		Fields:        GetCallsReportResponseParametersFields,
+	}
+}
+
+// init set defaults and reset seen communications
+func (w *Watcher) init() {
+	if w.Overlap <= 0 {
+		w.Overlap = 15 * time.Minute
+	}
+	if w.MaxCallDuration <= 0 {
+		w.MaxCallDuration = 4 * time.Hour
+	}
+	if w.PollInterval <= 0 {
+		w.PollInterval = time.Minute
+	}
+	if w.ReconcileInterval <= 0 {
+		w.ReconcileInterval = 10 * time.Minute
+	}
+	if w.NotificationTimeout <= 0 {
+		w.NotificationTimeout = 5 * time.Minute
+	}
+	w.seen = map[int64]seenCommunication{}
+	w.Fields = withFields(w.Fields, watcherFields...)
+}
+
+// Run start watching until ctx done, returned channel closed after it
+func (w *Watcher) Run(ctx context.Context) <-chan Communication {
+	w.init()
+
+	out := make(chan Communication, 100)
+	wg := sync.WaitGroup{}
+
+	if w.Notifications != nil {
+		events := w.Notifications.Events(100)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case e := <-events:
+					w.notification(ctx, e, out)
+				}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		from := w.From
+		if from.IsZero() {
+			from = time.Now().Add(-w.Overlap)
+		}
+		for {
+			till := time.Now()
+			err := w.poll(ctx, from, till, out)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				w.error(err)
+			} else {
+				// next window start from last successful poll with overlap, so missed rows come again,
+				// or from not finished call, so its finish polled if notification missed
+				from = till.Add(-w.Overlap)
+				if t := w.oldestOpen(till.Add(-w.MaxCallDuration)); !t.IsZero() && t.Before(from) {
+					from = t
+				}
+				w.prune(from)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.interval()):
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+func (w *Watcher) interval() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.Notifications != nil && time.Since(w.lastNotification) < w.NotificationTimeout {
+		return w.ReconcileInterval
+	}
+	return w.PollInterval
+}
+
+func (w *Watcher) notification(ctx context.Context, e Event, out chan<- Communication) {
+	w.mu.Lock()
+	w.lastNotification = time.Now()
+	w.mu.Unlock()
+
+	id := e.CommunicationID
+	if id == 0 {
+		return
+	}
+	updated, emit := w.mark(id, eventCallState(e), e.StartTime)
+	if !emit {
+		return
+	}
+	select {
+	case out <- Communication{ID: id, Source: CommunicationSourceNotification, Updated: updated, Event: &e}:
+	case <-ctx.Done():
+	}
+}
+
+func (w *Watcher) poll(ctx context.Context, from, till time.Time, out chan<- Communication) error {
+	const limit = 1000
+	var rows []map[string]any
+	for offset := 0; ; offset += limit {
+		resp, err := w.Client.GetCalls(ctx, w.UserID, from, till, limit, offset, nil, w.Fields...)
+		if err != nil {
+			return err
+		}
+		m, _ := resp.(map[string]any)
+		data, ok := m["data"].([]any)
+		if !ok {
+			return fmt.Errorf("response not have field 'data'")
+		}
+		for i := range data {
+			row, ok := data[i].(map[string]any)
+			if !ok {
+				return fmt.Errorf("wrong row type %T", data[i])
+			}
+			rows = append(rows, row)
+		}
+		if len(data) < limit {
+			break
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return fmt.Sprint(rows[i]["start_time"]) < fmt.Sprint(rows[j]["start_time"])
+	})
+
+	for i := range rows {
+		id, err := rowInt64(rows[i]["communication_id"])
+		if err != nil {
+			return fmt.Errorf("row communication_id: %w", err)
+		}
+		startTime, _ := StringToTime(fmt.Sprint(rows[i]["start_time"]))
+		updated, emit := w.mark(id, rowCallState(rows[i]), startTime)
+		if !emit {
+			continue
+		}
+		select {
+		case out <- Communication{ID: id, Source: CommunicationSourcePoll, Updated: updated, Data: rows[i]}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// mark remember communication state, return is it update and need it emit
+func (w *Watcher) mark(id int64, state callState, startTime time.Time) (updated, emit bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s, ok := w.seen[id]
+	if ok && (state == s.state || state.stage < s.state.stage) {
+		return true, false
+	}
+	if startTime.IsZero() {
+		startTime = s.startTime
+	}
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+	w.seen[id] = seenCommunication{state: state, startTime: startTime}
+	return ok, true
+}
+
+// oldestOpen start time of the oldest not finished call started after since, zero if none
+func (w *Watcher) oldestOpen(since time.Time) time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var oldest time.Time
+	for _, s := range w.seen {
+		if s.state.stage == callStageFinished || s.startTime.Before(since) {
+			continue
+		}
+		if oldest.IsZero() || s.startTime.Before(oldest) {
+			oldest = s.startTime
+		}
+	}
+	return oldest
+}
+
+// prune forget communications started before polling window
+func (w *Watcher) prune(before time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for id, s := range w.seen {
+		if s.startTime.Before(before.Add(-w.Overlap)) {
+			delete(w.seen, id)
+		}
+	}
+}
+
+func (w *Watcher) error(err error) {
+	if w.Errors == nil {
+		return
+	}
+	select {
+	case w.Errors <- err:
+	default:
+	}
+}
+
+// withFields fields with missing required fields appended, fields not modified
+func withFields(fields []Field, required ...Field) []Field {
+	result := append([]Field{}, fields...)
+	for _, r := range required {
+		found := false
+		for _, f := range fields {
+			if f == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, r)
+		}
+	}
+	return result
+}
@@ -0,0 +1,195 @@
+package uiscom
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// reportServer JSON-RPC server answering every request with calls report rows
+func reportServer(t *testing.T, rows []map[string]any, fields *[]Field) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     any `json:"id"`
+			Params struct {
+				Fields []Field `json:"fields"`
+			} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %s", err)
+		}
+		if fields != nil {
+			*fields = req.Params.Fields
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  map[string]any{"data": rows},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWatcherDeduplicate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+	finish := start.Add(time.Minute)
+	finished := Event{
+		Type:            EventCallFinished,
+		CommunicationID: 42,
+		StartTime:       start,
+		FinishTime:      finish,
+		TalkDuration:    30 * time.Second,
+	}
+	finishedRow := map[string]any{
+		"id":               1,
+		"communication_id": 42,
+		"start_time":       TimeToString(start),
+		"finish_time":      TimeToString(finish),
+		"talk_duration":    30,
+		"is_lost":          false,
+	}
+	openRow := map[string]any{
+		"id":               1,
+		"communication_id": 42,
+		"start_time":       TimeToString(start),
+		"finish_time":      nil,
+		"talk_duration":    0,
+		"is_lost":          false,
+	}
+	longerRow := map[string]any{}
+	for k, v := range finishedRow {
+		longerRow[k] = v
+	}
+	longerRow["talk_duration"] = 31
+
+	tests := []struct {
+		name    string
+		events  []Event
+		rows    []map[string]any
+		emitted int
+		updated int
+	}{
+		{
+			name:    "finished notification then same poll row",
+			events:  []Event{finished},
+			rows:    []map[string]any{finishedRow},
+			emitted: 1,
+		},
+		{
+			name:    "started notification then finished poll row",
+			events:  []Event{{Type: EventCallStarted, CommunicationID: 42, StartTime: start}},
+			rows:    []map[string]any{finishedRow},
+			emitted: 2,
+			updated: 1,
+		},
+		{
+			name: "answered notification then open poll row",
+			events: []Event{
+				{Type: EventCallStarted, CommunicationID: 42, StartTime: start},
+				{Type: EventCallAnswered, CommunicationID: 42, StartTime: start},
+			},
+			rows:    []map[string]any{openRow},
+			emitted: 2,
+			updated: 1,
+		},
+		{
+			name:    "poll row with changed talk duration",
+			events:  []Event{finished},
+			rows:    []map[string]any{longerRow},
+			emitted: 2,
+			updated: 1,
+		},
+		{
+			name:    "notification without communication_id ignored",
+			events:  []Event{{Type: EventCallFinished, CallSessionID: 7, StartTime: start, FinishTime: finish}},
+			rows:    []map[string]any{finishedRow},
+			emitted: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []Field
+			server := reportServer(t, tt.rows, &fields)
+			w := NewWatcher(NewWithToken(Target(server.URL), "token"), nil)
+			w.init()
+
+			ctx := context.Background()
+			out := make(chan Communication, 10)
+			for _, e := range tt.events {
+				w.notification(ctx, e, out)
+			}
+			if err := w.poll(ctx, start.Add(-time.Hour), finish.Add(time.Hour), out); err != nil {
+				t.Fatal(err)
+			}
+			close(out)
+
+			var emitted, updated int
+			for c := range out {
+				if c.ID != 42 {
+					t.Errorf("communication id %d, expected 42", c.ID)
+				}
+				emitted++
+				if c.Updated {
+					updated++
+				}
+			}
+			if emitted != tt.emitted || updated != tt.updated {
+				t.Errorf("emitted %d updated %d, expected %d and %d", emitted, updated, tt.emitted, tt.updated)
+			}
+			for _, f := range watcherFields {
+				if !containsField(fields, f) {
+					t.Errorf("field %s not requested", f)
+				}
+			}
+		})
+	}
+}
+
+func TestWatcherOldestOpen(t *testing.T) {
+	now := time.Now()
+	w := &Watcher{}
+	w.init()
+	w.mark(1, callState{stage: callStageFinished}, now.Add(-3*time.Hour))
+	w.mark(2, callState{stage: callStageAnswered}, now.Add(-2*time.Hour))
+	w.mark(3, callState{stage: callStageStarted}, now.Add(-time.Hour))
+	w.mark(4, callState{stage: callStageStarted}, now.Add(-10*time.Hour))
+
+	if got := w.oldestOpen(now.Add(-4 * time.Hour)); !got.Equal(now.Add(-2 * time.Hour)) {
+		t.Errorf("oldest open %s, expected %s", got, now.Add(-2*time.Hour))
+	}
+	w.mark(2, callState{stage: callStageFinished}, time.Time{})
+	if got := w.oldestOpen(now.Add(-4 * time.Hour)); !got.Equal(now.Add(-time.Hour)) {
+		t.Errorf("oldest open %s, expected %s", got, now.Add(-time.Hour))
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	fields := []Field{"id", "talk_duration"}
+	got := withFields(fields, "communication_id", "talk_duration")
+	want := []Field{"id", "talk_duration", "communication_id"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, expected %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, expected %v", got, want)
+		}
+	}
+	if len(fields) != 2 {
+		t.Errorf("source fields modified: %v", fields)
+	}
+}
+
+func containsField(fields []Field, f Field) bool {
+	for i := range fields {
+		if fields[i] == f {
+			return true
+		}
+	}
+	return false
+}